@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// command is a genblog subcommand, e.g. `genblog serve -addr :8080`
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"build", "render the site into the output directory (default)", buildCommand},
+	{"serve", "build and serve the site, rebuilding it on changes", serveCommand},
+	{"new", "create a new post in the source directory", newCommand},
+	{"check", "parse templates and source files without writing anything", checkCommand},
+	{"stylesheet", "print CSS of the code highlighting style, for highlight_classes", stylesheetCommand},
+}
+
+// runCommand runs the subcommand named by the first argument.
+// When no subcommand is given it falls back to "build",
+// so that the GitHub Action keeps working without arguments.
+func runCommand(args []string) error {
+	name := "build"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		printUsage()
+		return nil
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(args)
+		}
+	}
+
+	printUsage()
+	return errors.Errorf("unknown command %q", name)
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: genblog <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun `genblog <command> -h` to see the flags of the command.\n")
+}
+
+// registerConfigFlags adds a flag for every config field that has an `env` tag,
+// e.g. INPUT_OUTPUT_DIRECTORY becomes -output-directory.
+// Current field values are used as flag defaults.
+func registerConfigFlags(fs *flag.FlagSet, c *config) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		envName := t.Field(i).Tag.Get("env")
+		if envName == "" {
+			continue
+		}
+
+		fs.Var(configValue{v.Field(i)}, flagName(envName), flagUsage(v.Field(i), envName))
+	}
+
+	for alias, name := range flagAliases {
+		fs.Var(fs.Lookup(name).Value, alias, "alias for -"+name)
+	}
+}
+
+// flagAliases are short names of config flags, e.g. `-j 4` is the same as `-workers 4`
+var flagAliases = map[string]string{
+	"j": "workers",
+}
+
+// flagUsage returns flag description with a placeholder for its value type,
+// see flag.UnquoteUsage
+func flagUsage(v reflect.Value, envName string) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return "overrides " + envName
+	case reflect.Slice:
+		return "comma-separated `list`, overrides " + envName
+	default:
+		return "`" + v.Kind().String() + "` value, overrides " + envName
+	}
+}
+
+// flagName converts environment variable name to flag name,
+// e.g. INPUT_BASE_PATH -> base-path
+func flagName(envName string) string {
+	name := strings.TrimPrefix(envName, "INPUT_")
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// configValue is a flag.Value that sets a config field using reflection.
+type configValue struct {
+	v reflect.Value
+}
+
+func (c configValue) String() string {
+	if !c.v.IsValid() { // zero value, used by flag package to print defaults
+		return ""
+	}
+
+	if s, ok := c.v.Interface().([]string); ok {
+		return strings.Join(s, ",")
+	}
+
+	return fmt.Sprint(c.v.Interface())
+}
+
+func (c configValue) Set(s string) error {
+	switch c.v.Kind() {
+	case reflect.String:
+		c.v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		c.v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		c.v.SetInt(int64(n))
+	case reflect.Slice:
+		c.v.Set(reflect.ValueOf(strings.Split(s, ",")))
+	default:
+		return errors.Errorf("unsupported flag type %s", c.v.Type())
+	}
+	return nil
+}
+
+// IsBoolFlag allows to use boolean flags without value, e.g. -show-drafts
+func (c configValue) IsBoolFlag() bool {
+	return c.v.IsValid() && c.v.Kind() == reflect.Bool
+}
+
+func buildCommand(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	if err := loadConfig(fs, args); err != nil {
+		return err
+	}
+
+	log.Println("Starting")
+	t := time.Now()
+
+	if err := run(); err != nil {
+		return err
+	}
+
+	log.Printf("Finished in %dms", time.Since(t).Milliseconds())
+	return nil
+}
+
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	watchChanges := fs.Bool("watch", true, "rebuild on changes and reload open pages")
+	if err := loadConfig(fs, args); err != nil {
+		return err
+	}
+
+	return serve(*addr, *watchChanges)
+}
+
+func newCommand(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	title := fs.String("title", "", "post title")
+	tags := fs.String("tags", "", "comma-separated list of post tags")
+	contentType := fs.String("type", "post", "content type, selects the archetype: post, page, note, ...")
+	translations := fs.Bool("translations", false, "also create translations for every language in config file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: genblog new [flags] <path>\n")
+		fs.PrintDefaults()
+	}
+	if err := loadConfig(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("path to the new post is required")
+	}
+
+	return createPost(
+		fs.Arg(0),
+		newArchetypeData(*title, *tags, *contentType, time.Now().In(cfg.location())),
+		*translations,
+	)
+}
+
+func checkCommand(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	if err := loadConfig(fs, args); err != nil {
+		return err
+	}
+
+	return check()
+}
+
+func stylesheetCommand(args []string) error {
+	fs := flag.NewFlagSet("stylesheet", flag.ExitOnError)
+	output := fs.String("o", "", "write CSS to the file instead of stdout")
+	if err := loadConfig(fs, args); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return writeStylesheet(os.Stdout)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return errors.Wrapf(err, "create %s", *output)
+	}
+	defer f.Close()
+
+	return writeStylesheet(f)
+}
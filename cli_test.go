@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagName(t *testing.T) {
+	tests := []struct {
+		envName  string
+		flagName string
+	}{
+		{
+			envName:  "INPUT_BASE_PATH",
+			flagName: "base-path",
+		},
+		{
+			envName:  "INPUT_THUMB_MAX_WIDTH",
+			flagName: "thumb-max-width",
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.flagName, flagName(test.envName))
+	}
+}
+
+func TestRegisterConfigFlags(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+		cfg  config
+	}{
+		{
+			desc: "No flags keep current values",
+			args: []string{},
+			cfg:  config{OutputDirectory: "output"},
+		},
+		{
+			desc: "String flag",
+			args: []string{"-output-directory", "public"},
+			cfg:  config{OutputDirectory: "public"},
+		},
+		{
+			desc: "Bool flag without value",
+			args: []string{"-show-drafts"},
+			cfg:  config{OutputDirectory: "output", ShowDrafts: true},
+		},
+		{
+			desc: "Alias flag",
+			args: []string{"-j", "4"},
+			cfg:  config{OutputDirectory: "output", Workers: 4},
+		},
+		{
+			desc: "Int and slice flags",
+			args: []string{"-thumb-max-width=200", "-allowed-file-extensions", ".png,.gif"},
+			cfg: config{
+				OutputDirectory:       "output",
+				ThumbMaxWidth:         200,
+				AllowedFileExtensions: []string{".png", ".gif"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		c := config{OutputDirectory: "output"}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		registerConfigFlags(fs, &c)
+
+		require.NoError(t, fs.Parse(test.args), test.desc)
+		require.Equal(t, test.cfg, c, test.desc)
+	}
+}
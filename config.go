@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v6"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are looked up in the source directory, the first one found is used
+var configFiles = []string{"genblog.toml", "genblog.yaml", "genblog.yml"}
+
+// languageConfig holds settings of one language of the site, e.g.
+//
+//	[languages.ru]
+//	name = "Русский"
+//	comments_enabled = false
+//	words_per_minute = 180
+//	typography = true
+type languageConfig struct {
+	Name            string `toml:"name" yaml:"name"`                         // language name to show in templates
+	CommentsEnabled *bool  `toml:"comments_enabled" yaml:"comments_enabled"` // overrides config.CommentsEnabled for posts in this language
+	WordsPerMinute  int    `toml:"words_per_minute" yaml:"words_per_minute"` // overrides config.WordsPerMinute for posts in this language
+	Typography      *bool  `toml:"typography" yaml:"typography"`             // overrides config.Typography for posts in this language
+}
+
+// thumbnailPreset defines additional thumbnail size, created for every image
+// along with the default thumbnail, e.g.
+//
+//	[thumbnails.large]
+//	path = "thumb_large"
+//	max_width = 800
+//	max_height = 600
+type thumbnailPreset struct {
+	Path      string `toml:"path" yaml:"path"` // path to thumbnails directory, "<thumb_path>_<preset name>" by default
+	MaxWidth  int    `toml:"max_width" yaml:"max_width"`
+	MaxHeight int    `toml:"max_height" yaml:"max_height"`
+}
+
+// isConfigFile reports whether the path is the config file in use (cfg.ConfigFile),
+// so that it's not treated as a translation file
+func isConfigFile(path string) bool {
+	if cfg.ConfigFile == "" {
+		return false
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	configAbs, err := filepath.Abs(cfg.ConfigFile)
+	return err == nil && abs == configAbs
+}
+
+// loadConfig fills the global cfg, later sources override earlier ones:
+// defaults (`envDefault` tags), config file, environment variables, command line flags.
+// Command-specific flags must be registered in fs before calling loadConfig.
+func loadConfig(fs *flag.FlagSet, args []string) error {
+	defaults := config{}
+	if err := env.Parse(&defaults, env.Options{Environment: map[string]string{}}); err != nil {
+		return errors.Wrap(err, "config defaults")
+	}
+
+	// environment variables and flags are parsed into separate structs
+	// to apply them on top of the config file
+	fromEnv := defaults
+	setInEnv := map[string]bool{}
+	if err := env.Parse(&fromEnv, env.Options{
+		OnSet: func(key string, value interface{}, isDefault bool) {
+			if !isDefault && value != "" {
+				setInEnv[key] = true
+			}
+		},
+	}); err != nil {
+		return errors.Wrap(err, "environment variables parsing")
+	}
+
+	fromFlags := defaults
+	registerConfigFlags(fs, &fromFlags)
+
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "flags parsing")
+	}
+
+	setInFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		if name, ok := flagAliases[f.Name]; ok {
+			setInFlags[name] = true
+			return
+		}
+		setInFlags[f.Name] = true
+	})
+	isSetInFlags := func(envName string) bool { return setInFlags[flagName(envName)] }
+	isSetInEnv := func(envName string) bool { return setInEnv[envName] }
+
+	// config file location may be changed by environment variables or flags
+	location := defaults
+	applyConfig(&location, &fromEnv, isSetInEnv)
+	applyConfig(&location, &fromFlags, isSetInFlags)
+
+	cfg = defaults
+	if err := readConfigFile(&cfg, location.ConfigFile, location.SourceDirectory); err != nil {
+		return err
+	}
+	applyConfig(&cfg, &fromEnv, isSetInEnv)
+	applyConfig(&cfg, &fromFlags, isSetInFlags)
+	cfg.ConfigFile = findConfigFile(location.ConfigFile, location.SourceDirectory)
+
+	if cfg.DefaultLanguage == "" {
+		cfg.DefaultLanguage = "en"
+	}
+
+	timezone, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return errors.Wrapf(err, "invalid timezone %q", cfg.Timezone)
+	}
+	cfg.timezone = timezone
+
+	if err := cfg.validatePermalinks(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateRedirects(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateMarkdownOptions(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateHighlight(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyConfig copies fields with `env` tag from src to dst,
+// if isSet returns true for the environment variable name
+func applyConfig(dst, src *config, isSet func(envName string) bool) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+
+	for i := 0; i < d.NumField(); i++ {
+		envName := d.Type().Field(i).Tag.Get("env")
+		if envName != "" && isSet(envName) {
+			d.Field(i).Set(s.Field(i))
+		}
+	}
+}
+
+// findConfigFile returns the path, or the first of configFiles in dir if the path is empty,
+// "" if there is no config file
+func findConfigFile(path, dir string) string {
+	if path != "" {
+		return path
+	}
+
+	for _, name := range configFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+
+	return ""
+}
+
+// readConfigFile reads TOML or YAML config file into c,
+// fields that are missing in the file keep their values.
+// If path is empty, it looks up configFiles in dir and does nothing if none exist.
+func readConfigFile(c *config, path, dir string) error {
+	path = findConfigFile(path, dir)
+	if path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "read config file %q", path)
+	}
+
+	switch filepath.Ext(path) {
+	case ".toml":
+		meta, err := toml.Decode(string(b), c)
+		if err != nil {
+			return errors.Wrapf(err, "parse config file %q", path)
+		}
+
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			sort.Strings(keys)
+			return errors.Errorf("unknown keys in config file %q: %s", path, strings.Join(keys, ", "))
+		}
+
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(b))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && err != io.EOF {
+			return errors.Wrapf(err, "parse config file %q", path)
+		}
+
+	default:
+		return errors.Errorf("unsupported config file format %q, use .toml or .yaml", path)
+	}
+
+	return nil
+}
+
+// language returns settings of the language,
+// empty settings if the language is not configured
+func (c config) language(lang string) languageConfig {
+	return c.Languages[lang]
+}
+
+// commentsEnabled returns default value of MarkdownFile.CommentsEnabled for the language
+func (c config) commentsEnabled(lang string) *bool {
+	enabled := c.CommentsEnabled
+	if l := c.language(lang); l.CommentsEnabled != nil {
+		enabled = *l.CommentsEnabled
+	}
+	return &enabled
+}
+
+// location returns time zone of post dates loaded by loadConfig, UTC if the config is not loaded
+func (c config) location() *time.Location {
+	if c.timezone == nil {
+		return time.UTC
+	}
+	return c.timezone
+}
+
+// thumbnailPaths returns paths of thumbnails for every preset in config.Thumbnails,
+// based on the default thumbnail path, e.g. thumb/2022/image.png -> thumb_large/2022/image.png
+func (c config) thumbnailPaths(thumbPath string) map[string]string {
+	if len(c.Thumbnails) == 0 {
+		return nil
+	}
+
+	paths := make(map[string]string, len(c.Thumbnails))
+	for name, preset := range c.Thumbnails {
+		dir := preset.Path
+		if dir == "" {
+			dir = c.ThumbPath + "_" + name
+		}
+		paths[name] = dir + strings.TrimPrefix(thumbPath, c.ThumbPath)
+	}
+	return paths
+}
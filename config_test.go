@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	tests := []struct {
+		desc     string
+		filename string
+		content  string
+		cfg      config
+	}{
+		{
+			desc:     "TOML",
+			filename: "genblog.toml",
+			content:  "output_directory = \"public\"\nthumb_max_width = 200\n\n[languages.ru]\nname = \"Русский\"\n\n[thumbnails.large]\nmax_width = 800\n",
+			cfg: config{
+				OutputDirectory: "public",
+				ThumbPath:       "thumb",
+				ThumbMaxWidth:   200,
+				Languages:       map[string]languageConfig{"ru": {Name: "Русский"}},
+				Thumbnails:      map[string]thumbnailPreset{"large": {MaxWidth: 800}},
+			},
+		},
+		{
+			desc:     "YAML",
+			filename: "genblog.yaml",
+			content:  "output_directory: public\nallowed_file_extensions: [.png]\nlanguages:\n  ru:\n    comments_enabled: false\n",
+			cfg: config{
+				OutputDirectory:       "public",
+				ThumbPath:             "thumb",
+				AllowedFileExtensions: []string{".png"},
+				Languages:             map[string]languageConfig{"ru": {CommentsEnabled: boolPtr(false)}},
+			},
+		},
+		{
+			desc:     "Empty YAML",
+			filename: "genblog.yml",
+			content:  "",
+			cfg:      config{ThumbPath: "thumb"},
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, test.filename), []byte(test.content), permFile))
+
+		c := config{ThumbPath: "thumb"}
+		require.NoError(t, readConfigFile(&c, "", dir), test.desc)
+		require.Equal(t, test.cfg, c, test.desc)
+	}
+}
+
+func TestReadConfigFileUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "genblog.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("output_dir = \"public\"\n"), permFile))
+
+	err := readConfigFile(&config{}, path, "")
+	require.EqualError(t, err, `unknown keys in config file "`+path+`": output_dir`)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "genblog.toml"),
+		[]byte("output_directory = \"from_file\"\nstatic_directory = \"from_file\"\nthumb_path = \"from_file\"\n"),
+		permFile,
+	))
+
+	t.Setenv("INPUT_SOURCE_DIRECTORY", dir)
+	t.Setenv("INPUT_STATIC_DIRECTORY", "from_env")
+	t.Setenv("INPUT_THUMB_PATH", "from_env")
+	t.Setenv("INPUT_BASE_PATH", "") // empty variables don't override the file
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	require.NoError(t, loadConfig(fs, []string{"-thumb-path", "from_flag"}))
+
+	require.Equal(t, "from_file", cfg.OutputDirectory)
+	require.Equal(t, "from_env", cfg.StaticDirectory)
+	require.Equal(t, "from_flag", cfg.ThumbPath)
+	require.Equal(t, "_templates", cfg.TemplatesDirectory) // default
+}
+
+// inputs with defaults are always passed to the action as INPUT_* variables,
+// so they would override the config file
+func TestActionInputsHaveNoDefaults(t *testing.T) {
+	b, err := ioutil.ReadFile("action.yml")
+	require.NoError(t, err)
+
+	var action struct {
+		Inputs map[string]map[string]interface{} `yaml:"inputs"`
+	}
+	require.NoError(t, yaml.Unmarshal(b, &action))
+	require.NotEmpty(t, action.Inputs)
+
+	for name, input := range action.Inputs {
+		require.NotContains(t, input, "default", name)
+	}
+}
+
+func TestIsConfigFile(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	tests := []struct {
+		configFile string
+		path       string
+		isConfig   bool
+	}{
+		{"genblog.toml", "genblog.toml", true},
+		{"content/genblog.toml", "content/genblog.toml", true},
+		{"content/site.toml", "content/../content/site.toml", true},
+		{"genblog.toml", "content/genblog.toml", false},
+		{"", "genblog.toml", false},
+		{"genblog.toml", "messages.ru.toml", false},
+	}
+
+	for _, test := range tests {
+		cfg = config{ConfigFile: test.configFile}
+		require.Equal(t, test.isConfig, isConfigFile(test.path), test.path)
+	}
+}
+
+func TestLoadConfigResolvesConfigFile(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "genblog.yaml"), []byte("thumb_path: x\n"), permFile))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "site.toml"), []byte("thumb_path = \"y\"\n"), permFile))
+
+	t.Setenv("INPUT_SOURCE_DIRECTORY", dir)
+	require.NoError(t, loadConfig(flag.NewFlagSet("test", flag.ContinueOnError), nil))
+	require.Equal(t, filepath.Join(dir, "genblog.yaml"), cfg.ConfigFile)
+
+	custom := filepath.Join(dir, "site.toml")
+	require.NoError(t, loadConfig(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config-file", custom}))
+	require.Equal(t, "y", cfg.ThumbPath)
+	require.True(t, isConfigFile(custom))
+}
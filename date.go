@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// dateLayouts are formats of `date` accepted in front matter,
+// dates without time zone are in cfg.Timezone
+var dateLayouts = []string{
+	time.RFC3339Nano, // 2006-01-02T15:04:05Z07:00, fractional seconds are optional
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// date is a date of the post, e.g.
+//
+//	date: 2022-01-02
+//	date: 2022-01-02 15:04
+//	date: 2022-01-02T15:04:05+03:00
+//
+// In templates it's printed the same way it's written in front matter,
+// use `.Date.Format "Jan 2, 2006"` to change the format.
+type date struct {
+	time.Time
+	layout string // layout used in front matter
+}
+
+// parseDate parses date in one of dateLayouts, in the loc time zone
+// if the date has no time zone
+func parseDate(s string, loc *time.Location) (date, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return date{}, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return date{Time: t, layout: layout}, nil
+		}
+	}
+
+	return date{}, errors.Errorf(
+		"invalid date %q, expected 2006-01-02, 2006-01-02 15:04, 2006-01-02 15:04 -0700 or 2006-01-02T15:04:05Z07:00",
+		s,
+	)
+}
+
+func (d *date) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+
+	parsed, err := parseDate(s, cfg.location())
+	if err != nil {
+		return errors.Errorf("line %d: %v", value.Line, err)
+	}
+
+	*d = parsed
+	return nil
+}
+
+func (d date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	if d.layout == "" {
+		return d.Format("2006-01-02")
+	}
+	return d.Format(d.layout)
+}
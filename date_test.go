@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc   string
+		in     string
+		time   time.Time
+		out    string
+		errMsg string
+	}{
+		{
+			desc: "Date only",
+			in:   "2022-01-02",
+			time: time.Date(2022, 1, 2, 0, 0, 0, 0, moscow),
+			out:  "2022-01-02",
+		},
+		{
+			desc: "Date and time without zone",
+			in:   "2022-01-02 15:04",
+			time: time.Date(2022, 1, 2, 15, 4, 0, 0, moscow),
+			out:  "2022-01-02 15:04",
+		},
+		{
+			desc: "Date and time with zone offset",
+			in:   "2022-01-02 15:04 -0500",
+			time: time.Date(2022, 1, 2, 20, 4, 0, 0, time.UTC),
+			out:  "2022-01-02 15:04 -0500",
+		},
+		{
+			desc: "RFC 3339",
+			in:   "2022-01-02T15:04:05Z",
+			time: time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC),
+			out:  "2022-01-02T15:04:05Z",
+		},
+		{
+			desc: "Empty date",
+			in:   "",
+			out:  "",
+		},
+		{
+			desc:   "Invalid date",
+			in:     "2022-13-01",
+			errMsg: `invalid date "2022-13-01", expected 2006-01-02, 2006-01-02 15:04, 2006-01-02 15:04 -0700 or 2006-01-02T15:04:05Z07:00`,
+		},
+	}
+
+	for _, test := range tests {
+		d, err := parseDate(test.in, moscow)
+		if test.errMsg != "" {
+			require.EqualError(t, err, test.errMsg, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.True(t, test.time.Equal(d.Time), test.desc)
+		require.Equal(t, test.out, d.String(), test.desc)
+	}
+}
+
+func TestDateTimezone(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	t.Setenv("INPUT_SOURCE_DIRECTORY", t.TempDir())
+	t.Setenv("INPUT_TIMEZONE", "America/New_York")
+	require.NoError(t, loadConfig(flag.NewFlagSet("test", flag.ContinueOnError), nil))
+
+	md, err := processMarkdownFileContent("post.md", []byte("---\ndate: 2022-01-02 10:00\n---\n"))
+	require.NoError(t, err)
+	require.Equal(t, "2022-01-02T15:00:00Z", md.Date.UTC().Format(time.RFC3339))
+
+	md, err = processMarkdownFileContent("post.md", []byte("+++\ndate = 2022-01-02T10:00:00\n+++\n"))
+	require.NoError(t, err)
+	require.Equal(t, "2022-01-02T15:00:00Z", md.Date.UTC().Format(time.RFC3339))
+}
+
+func TestInvalidDate(t *testing.T) {
+	cfg = config{}
+
+	_, err := processMarkdownFileContent("post.md", []byte("---\ntitle: Post\ndate: 2022-02-30\n---\n"))
+	require.EqualError(
+		t,
+		err,
+		`failed to process markdown file: parsing metadata: reading metadata: `+
+			`line 3: invalid date "2022-02-30", expected 2006-01-02, 2006-01-02 15:04, 2006-01-02 15:04 -0700 or 2006-01-02T15:04:05Z07:00`,
+	)
+}
+
+func TestByCreated(t *testing.T) {
+	cfg = config{}
+
+	var files []*MarkdownFile
+	for _, d := range []string{"2022-01-02 09:00", "", "2022-01-02 18:30", "2021-12-31", "2022-01-02"} {
+		parsed, err := parseDate(d, time.UTC)
+		require.NoError(t, err)
+		files = append(files, &MarkdownFile{Date: parsed})
+	}
+
+	sort.Sort(ByCreated(files))
+
+	var dates []string
+	for _, file := range files {
+		dates = append(dates, file.Date.String())
+	}
+	require.Equal(t, []string{"2022-01-02 18:30", "2022-01-02 09:00", "2022-01-02", "2021-12-31", ""}, dates)
+}
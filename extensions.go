@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/pkg/errors"
+)
+
+// markdownExtensions are parser extensions that can be turned on and off
+// with config.MarkdownExtensions and `markdown_extensions` metadata
+var markdownExtensions = map[string]parser.Extensions{
+	"footnotes":        parser.Footnotes,
+	"definition_lists": parser.DefinitionLists,
+	"tables":           parser.Tables,
+	"strikethrough":    parser.Strikethrough,
+	"hard_line_breaks": parser.HardLineBreak,
+	"attributes":       parser.Attributes,
+	"mathjax":          parser.MathJax,
+}
+
+// markdownFlags are HTML renderer flags that can be turned on and off
+// with config.MarkdownFlags and `markdown_flags` metadata
+var markdownFlags = map[string]mdhtml.Flags{
+	"href_target_blank": mdhtml.HrefTargetBlank,
+	"nofollow_links":    mdhtml.NofollowLinks,
+	"noreferrer_links":  mdhtml.NoreferrerLinks,
+	"smartypants": mdhtml.Smartypants | mdhtml.SmartypantsFractions |
+		mdhtml.SmartypantsDashes | mdhtml.SmartypantsLatexDashes,
+}
+
+// markdownOptions are parser extensions and renderer flags used to render a post
+type markdownOptions struct {
+	extensions parser.Extensions
+	flags      mdhtml.Flags
+	highlight  bool   // highlight fenced code blocks, see highlightCode
+	sidenotes  bool   // render footnotes as sidenotes, see footnotes
+	math       bool   // render math to MathML, see renderMath
+	source     string // path to the post, used in warnings
+}
+
+// defaultMarkdownOptions are the same as gomarkdown defaults,
+// extensions that are not in markdownExtensions are always on
+var defaultMarkdownOptions = markdownOptions{
+	extensions: parser.CommonExtensions,
+	flags:      mdhtml.CommonFlags,
+}
+
+// validateMarkdownOptions checks that config.MarkdownExtensions and config.MarkdownFlags
+// have only known names
+func (c config) validateMarkdownOptions() error {
+	_, err := defaultMarkdownOptions.apply(c.MarkdownExtensions, c.MarkdownFlags)
+	return err
+}
+
+// markdownOptions returns options of the post: defaults, changed by the site config
+// and then by `markdown_extensions` and `markdown_flags` of the post
+func (c config) markdownOptions(md *MarkdownFile) (markdownOptions, error) {
+	opts, err := defaultMarkdownOptions.apply(c.MarkdownExtensions, c.MarkdownFlags)
+	if err != nil {
+		return opts, err
+	}
+	opts.highlight = c.Highlight
+	opts.math = c.Math
+	opts.source = md.Source
+
+	opts, err = opts.apply(md.MarkdownExtensions, md.MarkdownFlags)
+	if err != nil {
+		return opts, err
+	}
+
+	// sidenotes are footnotes, so they are turned on too
+	if md.Sidenotes {
+		opts.extensions |= parser.Footnotes
+		opts.sidenotes = true
+	}
+
+	return opts, nil
+}
+
+// apply turns on extensions and flags by name, names with "-" prefix are turned off,
+// e.g. ["footnotes", "-tables"]
+func (o markdownOptions) apply(extensions, flags []string) (markdownOptions, error) {
+	for _, name := range extensions {
+		name, on := toggle(name)
+		if name == "" {
+			continue
+		}
+
+		ext, ok := markdownExtensions[name]
+		if !ok {
+			return o, errors.Errorf("unknown markdown extension %q", name)
+		}
+
+		if on {
+			o.extensions |= ext
+		} else {
+			o.extensions &^= ext
+		}
+	}
+
+	for _, name := range flags {
+		name, on := toggle(name)
+		if name == "" {
+			continue
+		}
+
+		flag, ok := markdownFlags[name]
+		if !ok {
+			return o, errors.Errorf("unknown markdown flag %q", name)
+		}
+
+		if on {
+			o.flags |= flag
+		} else {
+			o.flags &^= flag
+		}
+	}
+
+	return o, nil
+}
+
+// toggle returns name without "-" prefix and false if it had one
+func toggle(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "-") {
+		return strings.TrimSpace(name[1:]), false
+	}
+	return name, true
+}
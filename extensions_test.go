@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownOptionsApply(t *testing.T) {
+	tests := []struct {
+		desc       string
+		extensions []string
+		flags      []string
+		opts       markdownOptions
+		err        string
+	}{
+		{
+			desc: "Defaults",
+			opts: defaultMarkdownOptions,
+		},
+		{
+			desc:       "Turn on and off",
+			extensions: []string{"footnotes", "-tables", " - mathjax", ""},
+			flags:      []string{"href_target_blank", "-smartypants"},
+			opts: markdownOptions{
+				extensions: (parser.CommonExtensions | parser.Footnotes) &^ parser.Tables &^ parser.MathJax,
+				flags:      mdhtml.HrefTargetBlank,
+			},
+		},
+		{
+			desc:       "Unknown extension",
+			extensions: []string{"-emoji"},
+			err:        `unknown markdown extension "emoji"`,
+		},
+		{
+			desc:  "Unknown flag",
+			flags: []string{"toc"},
+			err:   `unknown markdown flag "toc"`,
+		},
+	}
+
+	for _, test := range tests {
+		opts, err := defaultMarkdownOptions.apply(test.extensions, test.flags)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.opts, opts, test.desc)
+	}
+}
+
+func TestProcessMarkdownOptions(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	tests := []struct {
+		desc    string
+		cfg     config
+		content string
+		body    string
+		err     string
+	}{
+		{
+			desc:    "Defaults",
+			content: "Line one\nline two ~~old~~ \"quoted\" [link](https://example.com)",
+			body:    "<p>Line one\nline two <del>old</del> &ldquo;quoted&rdquo; <a href=\"https://example.com\">link</a></p>\n",
+		},
+		{
+			desc:    "Site config",
+			cfg:     config{MarkdownExtensions: []string{"hard_line_breaks", "-strikethrough"}, MarkdownFlags: []string{"nofollow_links"}},
+			content: "Line one\nline two ~~old~~ [link](https://example.com)",
+			body:    "<p>Line one<br>\nline two ~~old~~ <a href=\"https://example.com\" rel=\"nofollow\">link</a></p>\n",
+		},
+		{
+			desc:    "Post overrides site config",
+			cfg:     config{MarkdownExtensions: []string{"hard_line_breaks"}, MarkdownFlags: []string{"-smartypants"}},
+			content: "---\nmarkdown_extensions: [-hard_line_breaks, footnotes]\nmarkdown_flags: [smartypants]\n---\nLine one\nline \"two\"[^1]\n\n[^1]: Note",
+			body: "<p>Line one\nline &ldquo;two&rdquo;<sup class=\"footnote-ref\" id=\"fnref:1\"><a href=\"#fn:1\">1</a></sup></p>\n\n" +
+				"<div class=\"footnotes\">\n\n<hr>\n\n<ol>\n<li id=\"fn:1\">Note</li>\n</ol>\n\n</div>\n",
+		},
+		{
+			desc:    "Unknown extension in metadata",
+			content: "---\nmarkdown_extensions: [emoji]\n---\nText",
+			err:     `failed to process markdown file: unknown markdown extension "emoji"`,
+		},
+	}
+
+	for _, test := range tests {
+		cfg = test.cfg
+		md, err := processMarkdownFileContent("post.md", []byte(test.content))
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.body, md.Body, test.desc)
+	}
+}
+
+func TestValidateMarkdownOptions(t *testing.T) {
+	require.NoError(t, config{MarkdownExtensions: []string{"footnotes"}, MarkdownFlags: []string{"-smartypants"}}.validateMarkdownOptions())
+	require.EqualError(t, config{MarkdownFlags: []string{"smartypants", "target_blank"}}.validateMarkdownOptions(), `unknown markdown flag "target_blank"`)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Front matter formats
+const (
+	frontMatterYAML = "yaml" // delimited with "---" lines
+	frontMatterTOML = "toml" // delimited with "+++" lines
+	frontMatterJSON = "json" // JSON object at the beginning of the file
+)
+
+var (
+	jsonFrontMatterStart = regexp.MustCompile(`^\{\s*("|\})`)
+	yamlErrorLine        = regexp.MustCompile(`line (\d+): `)
+)
+
+// splitMetadataAndBody splits file content into metadata (front matter) and body.
+// Metadata lines keep their numbers from the source file,
+// so that line numbers in parsing errors point to the source file.
+func splitMetadataAndBody(b []byte) (format string, metadata, body []byte, err error) {
+	for _, f := range []struct {
+		format    string
+		delimiter []byte
+	}{
+		{frontMatterYAML, []byte("---")},
+		{frontMatterTOML, []byte("+++")},
+	} {
+		if bytes.HasPrefix(b, f.delimiter) {
+			if parts := bytes.SplitN(b, f.delimiter, 3); len(parts) == 3 {
+				return f.format, parts[1], parts[2], nil
+			}
+		}
+	}
+
+	if jsonFrontMatterStart.Match(b) {
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			offset := decoder.InputOffset()
+			if syntaxErr, ok := err.(*json.SyntaxError); ok {
+				offset = syntaxErr.Offset - 1 // offset is after the invalid character
+			}
+			return "", nil, nil, errors.Errorf("json: line %d: %v", lineAt(b, int(offset)), err)
+		}
+
+		end := decoder.InputOffset()
+		return frontMatterJSON, b[:end], b[end:], nil
+	}
+
+	return "", []byte{}, b, nil
+}
+
+// lineAt returns line number of the byte offset, starting from 1
+func lineAt(b []byte, offset int) int {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
+
+// unmarshalFrontMatter decodes metadata of any format into v.
+// TOML and JSON are converted to YAML first, so that all formats
+// share the same field names (`yaml` tags) and custom unmarshalers.
+func unmarshalFrontMatter(format string, metadata []byte, v interface{}) error {
+	if format == frontMatterYAML {
+		return yaml.Unmarshal(metadata, v)
+	}
+
+	var values map[string]interface{}
+
+	switch format {
+	case frontMatterTOML:
+		if _, err := toml.Decode(string(metadata), &values); err != nil {
+			return errors.Wrap(err, "toml")
+		}
+	case frontMatterJSON:
+		if err := json.Unmarshal(metadata, &values); err != nil {
+			return errors.Wrap(err, "json")
+		}
+	default:
+		return errors.Errorf("unknown front matter format %q", format)
+	}
+
+	converted, err := yaml.Marshal(normalizeFrontMatter(values))
+	if err != nil {
+		return errors.Wrap(err, "convert front matter")
+	}
+
+	if err := yaml.Unmarshal(converted, v); err != nil {
+		return relocateErrorLines(err, converted, metadata)
+	}
+
+	return nil
+}
+
+// normalizeFrontMatter converts TOML dates to strings, the same way they are
+// written in YAML front matter, see dateLayouts
+func normalizeFrontMatter(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = normalizeFrontMatter(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeFrontMatter(item)
+		}
+	case []map[string]interface{}: // TOML array of tables
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = normalizeFrontMatter(item)
+		}
+		return result
+	case time.Time:
+		// TOML local dates and times are parsed in time.Local,
+		// they are written without time zone to be read in cfg.Timezone
+		if value.Location() == time.Local {
+			if h, m, s := value.Clock(); h == 0 && m == 0 && s == 0 && value.Nanosecond() == 0 {
+				return value.Format("2006-01-02")
+			}
+			return value.Format("2006-01-02T15:04:05")
+		}
+		return value.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// relocateErrorLines replaces line numbers in the YAML error for the converted
+// front matter with line numbers of the same keys in the original front matter
+func relocateErrorLines(err error, converted, original []byte) error {
+	convertedLines := strings.Split(string(converted), "\n")
+
+	msg := yamlErrorLine.ReplaceAllStringFunc(err.Error(), func(match string) string {
+		n, _ := strconv.Atoi(yamlErrorLine.FindStringSubmatch(match)[1])
+
+		// find top-level key the line belongs to
+		for i := n - 1; i >= 0 && i < len(convertedLines); i-- {
+			line := convertedLines[i]
+			if line == "" || line[0] == ' ' || line[0] == '-' {
+				continue
+			}
+
+			key := strings.SplitN(line, ":", 2)[0]
+			if sourceLine := keyLine(original, key); sourceLine > 0 {
+				return fmt.Sprintf("line %d: ", sourceLine)
+			}
+			break
+		}
+
+		return ""
+	})
+
+	return errors.New(msg)
+}
+
+// keyLine returns the line number of the key definition in TOML or JSON front matter,
+// 0 if not found
+func keyLine(metadata []byte, key string) int {
+	key = strings.Trim(key, `"'`)
+	re := regexp.MustCompile(`^\s*["']?` + regexp.QuoteMeta(key) + `["']?\s*[=:]`)
+
+	for i, line := range strings.Split(string(metadata), "\n") {
+		if re.MatchString(line) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrontMatterFormats(t *testing.T) {
+	expected := MarkdownFile{
+		Title:       "Blogpost",
+		Tags:        tags{"tagA", "tagB"},
+		ContentType: "page",
+		Draft:       true,
+	}
+
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc:    "YAML",
+			content: "---\ntitle: Blogpost\ndate: 2006-01-02\ntags: tagA, tagB\ntype: page\ndraft: true\n---\nPost body",
+		},
+		{
+			desc:    "YAML with list of tags",
+			content: "---\ntitle: Blogpost\ndate: 2006-01-02\ntags: [tagA, tagB]\ntype: page\ndraft: true\n---\nPost body",
+		},
+		{
+			desc:    "TOML",
+			content: "+++\ntitle = \"Blogpost\"\ndate = 2006-01-02\ntags = [\"tagA\", \"tagB\"]\ntype = \"page\"\ndraft = true\n+++\nPost body",
+		},
+		{
+			desc:    "TOML with datetime",
+			content: "+++\ntitle = \"Blogpost\"\ndate = \"2006-01-02\"\ntags = \"tagA, tagB\"\ntype = \"page\"\ndraft = true\n+++\nPost body",
+		},
+		{
+			desc:    "JSON",
+			content: "{\n  \"title\": \"Blogpost\",\n  \"date\": \"2006-01-02\",\n  \"tags\": [\"tagA\", \"tagB\"],\n  \"type\": \"page\",\n  \"draft\": true\n}\nPost body",
+		},
+	}
+
+	for _, test := range tests {
+		cfg = config{}
+		md, err := processMarkdownFileContent("post.md", []byte(test.content))
+		require.NoError(t, err, test.desc)
+
+		require.Equal(t, expected.Title, md.Title, test.desc)
+		require.Equal(t, "2006-01-02", md.Date.String(), test.desc)
+		require.Equal(t, expected.Tags, md.Tags, test.desc)
+		require.Equal(t, expected.ContentType, md.ContentType, test.desc)
+		require.Equal(t, expected.Draft, md.Draft, test.desc)
+		require.Equal(t, "<p>Post body</p>\n", md.Body, test.desc)
+	}
+}
+
+func TestFrontMatterErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		err     string
+	}{
+		{
+			desc:    "YAML syntax error",
+			content: "---\ntitle: Blogpost\ndate: [\n---\n",
+			err:     "parsing metadata: reading metadata: yaml: line 3: did not find expected node content",
+		},
+		{
+			desc:    "TOML syntax error",
+			content: "+++\ntitle = \"Blogpost\"\ndate = \n+++\n",
+			err:     "parsing metadata: reading metadata: toml: Near line 3 (last key parsed 'date'): expected value but found '\\n' instead",
+		},
+		{
+			desc:    "TOML type error",
+			content: "+++\ntitle = \"Blogpost\"\n\ndraft = \"maybe\"\n+++\n",
+			err:     "parsing metadata: reading metadata: yaml: unmarshal errors:\n  line 4: cannot unmarshal !!str `maybe` into bool",
+		},
+		{
+			desc:    "JSON syntax error",
+			content: "{\n  \"title\": \"Blogpost\",\n  \"draft\": tru\n}\n",
+			err:     "parsing metadata: json: line 3: invalid character '\\n' in literal true (expecting 'e')",
+		},
+		{
+			desc:    "JSON type error",
+			content: "{\n  \"title\": \"Blogpost\",\n  \"draft\": \"maybe\"\n}\n",
+			err:     "parsing metadata: reading metadata: yaml: unmarshal errors:\n  line 3: cannot unmarshal !!str `maybe` into bool",
+		},
+	}
+
+	for _, test := range tests {
+		cfg = config{}
+		_, err := processMarkdownFileContent("post.md", []byte(test.content))
+		require.EqualError(t, err, "failed to process markdown file: "+test.err, test.desc)
+	}
+}
+
+func TestJSONFrontMatterDetection(t *testing.T) {
+	format, metadata, body, err := splitMetadataAndBody([]byte("{{< youtube id >}}\nText"))
+	require.NoError(t, err)
+	require.Equal(t, "", format)
+	require.Equal(t, []byte{}, metadata)
+	require.Equal(t, "{{< youtube id >}}\nText", string(body))
+}
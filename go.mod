@@ -4,6 +4,8 @@ go 1.17
 
 require (
 	github.com/BurntSushi/toml v0.3.1
+	github.com/alecthomas/chroma/v2 v2.2.0
+	github.com/blevesearch/bleve/v2 v2.3.3
 	github.com/caarlos0/env/v6 v6.9.3
 	github.com/chuhlomin/search v0.0.5
 	github.com/disintegration/imaging v1.6.2
@@ -18,7 +20,6 @@ require (
 require (
 	github.com/RoaringBitmap/roaring v1.2.1 // indirect
 	github.com/bits-and-blooms/bitset v1.2.2 // indirect
-	github.com/blevesearch/bleve/v2 v2.3.3 // indirect
 	github.com/blevesearch/bleve_index_api v1.0.2 // indirect
 	github.com/blevesearch/geo v0.1.12 // indirect
 	github.com/blevesearch/go-porterstemmer v1.0.3 // indirect
@@ -35,6 +36,7 @@ require (
 	github.com/blevesearch/zapx/v14 v14.3.4 // indirect
 	github.com/blevesearch/zapx/v15 v15.3.4 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
 	github.com/golang/geo v0.0.0-20210211234256-740aa86cb551 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/pkg/errors"
+)
+
+var (
+	// fenceLine is the opening line of a fenced code block with attributes after the language,
+	// e.g. "```go {hl_lines=[3,5]}"
+	fenceLine = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^\\s{}`]+)[ \t]+\\{([^}\n]*)\\}[ \t]*$")
+	// fenceMarker is the opening or closing line of a fenced code block
+	fenceMarker = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	// fenceAttribute is key=value in fence attributes, value may be a list in brackets
+	fenceAttribute = regexp.MustCompile(`([a-z_]+)\s*=\s*(\[[^\]]*\]|"[^"]*"|[^\s,]+)`)
+)
+
+// fenceOptions are attributes of a fenced code block, e.g.
+//
+//	```go {linenos=true, linenostart=10, hl_lines=[3, "5-7"]}
+type fenceOptions struct {
+	lineNumbers     bool
+	lineNumberStart int
+	highlightLines  [][2]int // 1-based line ranges in the code block, inclusive
+}
+
+// validateHighlight checks that config.HighlightStyle is a known chroma style
+func (c config) validateHighlight() error {
+	if _, ok := styles.Registry[c.HighlightStyle]; !ok && c.HighlightStyle != "" {
+		return errors.Errorf("unknown highlight style %q", c.HighlightStyle)
+	}
+	return nil
+}
+
+// normalizeFences moves attributes of fenced code blocks into braces,
+// "```go {hl_lines=[3]}" -> "```{go hl_lines=[3]}", the only form the markdown parser
+// accepts for fences with attributes. Lines inside code blocks are not changed.
+func normalizeFences(b []byte) []byte {
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	closing := "" // marker of the open code block
+
+	for i, line := range lines {
+		text := strings.TrimRight(string(line), "\r\n")
+
+		if closing != "" {
+			if m := fenceMarker.FindStringSubmatch(text); m != nil && m[1][0] == closing[0] &&
+				len(m[1]) >= len(closing) && strings.TrimSpace(text[len(m[0]):]) == "" {
+				closing = ""
+			}
+			continue
+		}
+
+		if m := fenceLine.FindStringSubmatch(text); m != nil {
+			lines[i] = []byte(m[1] + m[2] + "{" + m[3] + " " + strings.TrimSpace(m[4]) + "}" + string(line[len(text):]))
+			closing = m[2]
+			continue
+		}
+
+		if m := fenceMarker.FindStringSubmatch(text); m != nil {
+			closing = m[1]
+		}
+	}
+
+	return bytes.Join(lines, nil)
+}
+
+// parseFenceInfo returns the language and options of a fenced code block
+// from its info string, e.g. "go hl_lines=[3,5]"
+func parseFenceInfo(info string) (string, fenceOptions) {
+	opts := fenceOptions{
+		lineNumbers:     cfg.HighlightLineNumbers,
+		lineNumberStart: 1,
+	}
+
+	lang := info
+	if i := strings.IndexAny(info, " \t"); i >= 0 {
+		lang = info[:i]
+	}
+
+	for _, m := range fenceAttribute.FindAllStringSubmatch(info[len(lang):], -1) {
+		value := strings.Trim(m[2], `"`)
+
+		switch m[1] {
+		case "linenos":
+			opts.lineNumbers = value != "false"
+		case "linenostart":
+			if n, err := strconv.Atoi(value); err == nil {
+				opts.lineNumberStart = n
+			}
+		case "hl_lines":
+			opts.highlightLines = parseLineRanges(value)
+		}
+	}
+
+	return lang, opts
+}
+
+// parseLineRanges parses line numbers and ranges, e.g. `[3, "5-7"]` or "3 5-7".
+// Invalid items are skipped.
+func parseLineRanges(s string) [][2]int {
+	var ranges [][2]int
+
+	items := strings.FieldsFunc(strings.Trim(s, "[]"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '"'
+	})
+
+	for _, item := range items {
+		from, to := item, item
+		if i := strings.Index(item, "-"); i > 0 {
+			from, to = item[:i], item[i+1:]
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil || end < start {
+			continue
+		}
+
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	return ranges
+}
+
+// highlightCode is a markdown renderer hook that highlights fenced code blocks with chroma.
+// Code blocks without language or in unknown languages are rendered as usual.
+// Highlighted code is rendered by the renderer as HTML block to keep new lines between blocks.
+func highlightCode(renderer *mdhtml.Renderer, w io.Writer, node ast.Node) (ast.WalkStatus, bool) {
+	block, ok := node.(*ast.CodeBlock)
+	if !ok || len(block.Info) == 0 {
+		return ast.GoToNext, false
+	}
+
+	lang, opts := parseFenceInfo(string(block.Info))
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		return ast.GoToNext, false
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(block.Literal))
+	if err != nil {
+		return ast.GoToNext, false
+	}
+
+	// chroma highlights lines by their numbers, not by position in the block
+	ranges := make([][2]int, len(opts.highlightLines))
+	for i, r := range opts.highlightLines {
+		ranges[i] = [2]int{r[0] + opts.lineNumberStart - 1, r[1] + opts.lineNumberStart - 1}
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(cfg.HighlightClasses),
+		chromahtml.WithLineNumbers(opts.lineNumbers),
+		chromahtml.BaseLineNumber(opts.lineNumberStart),
+		chromahtml.HighlightLines(ranges),
+	)
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, styles.Get(cfg.HighlightStyle), iterator); err != nil {
+		return ast.GoToNext, false
+	}
+
+	return renderer.RenderNode(w, &ast.HTMLBlock{Leaf: ast.Leaf{Literal: buf.Bytes()}}, true), true
+}
+
+// writeStylesheet writes CSS of config.HighlightStyle, to use with config.HighlightClasses
+func writeStylesheet(w io.Writer) error {
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	return formatter.WriteCSS(w, styles.Get(cfg.HighlightStyle))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeFences(t *testing.T) {
+	tests := []struct {
+		desc     string
+		markdown string
+		expected string
+	}{
+		{
+			desc:     "Attributes after language",
+			markdown: "Text\n\n```go {hl_lines=[3,5]}\ncode\n```\n",
+			expected: "Text\n\n```{go hl_lines=[3,5]}\ncode\n```\n",
+		},
+		{
+			desc:     "Tildes, indentation and spaces",
+			markdown: "  ~~~~ python   { linenos=true }  \r\ncode\r\n~~~~\r\n",
+			expected: "  ~~~~{python linenos=true}\r\ncode\r\n~~~~\r\n",
+		},
+		{
+			desc:     "Fences without attributes",
+			markdown: "```go\ncode\n```\n\n```{go}\ncode\n```\n",
+			expected: "```go\ncode\n```\n\n```{go}\ncode\n```\n",
+		},
+		{
+			desc:     "Fence inside code block",
+			markdown: "````md\n```go {hl_lines=[1]}\n```\n````\n\n```go {linenos=true}\n```",
+			expected: "````md\n```go {hl_lines=[1]}\n```\n````\n\n```{go linenos=true}\n```",
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, string(normalizeFences([]byte(test.markdown))), test.desc)
+	}
+}
+
+func TestParseFenceInfo(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	tests := []struct {
+		desc     string
+		info     string
+		cfg      config
+		lang     string
+		expected fenceOptions
+	}{
+		{
+			desc:     "Language only",
+			info:     "go",
+			lang:     "go",
+			expected: fenceOptions{lineNumberStart: 1},
+		},
+		{
+			desc:     "Line numbers from config",
+			info:     "go",
+			cfg:      config{HighlightLineNumbers: true},
+			lang:     "go",
+			expected: fenceOptions{lineNumbers: true, lineNumberStart: 1},
+		},
+		{
+			desc: "All attributes",
+			info: `go linenos=table, linenostart=10, hl_lines=[3, "5-7", "x", 9-8]`,
+			lang: "go",
+			expected: fenceOptions{
+				lineNumbers:     true,
+				lineNumberStart: 10,
+				highlightLines:  [][2]int{{3, 3}, {5, 7}},
+			},
+		},
+		{
+			desc:     "Line numbers turned off, ranges as a string",
+			info:     `js linenos=false hl_lines="1 4-5"`,
+			cfg:      config{HighlightLineNumbers: true},
+			lang:     "js",
+			expected: fenceOptions{lineNumberStart: 1, highlightLines: [][2]int{{1, 1}, {4, 5}}},
+		},
+	}
+
+	for _, test := range tests {
+		cfg = test.cfg
+		lang, opts := parseFenceInfo(test.info)
+		require.Equal(t, test.lang, lang, test.desc)
+		require.Equal(t, test.expected, opts, test.desc)
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	opts := defaultMarkdownOptions
+	opts.highlight = true
+
+	cfg = config{HighlightStyle: "github", HighlightClasses: true}
+	body, _ := renderMarkdown([]byte("```go {linenostart=5, hl_lines=[2]}\npackage main\nfunc main() {}\n```\n\n```unknown\nx\n```\n"), opts)
+	require.Equal(
+		t,
+		"<pre tabindex=\"0\" class=\"chroma\"><code>"+
+			"<span class=\"line\"><span class=\"cl\"><span class=\"kn\">package</span> <span class=\"nx\">main</span>\n</span></span>"+
+			"<span class=\"line hl\"><span class=\"cl\"><span class=\"kd\">func</span> <span class=\"nf\">main</span><span class=\"p\">()</span> <span class=\"p\">{}</span>\n</span></span>"+
+			"</code></pre>\n\n"+
+			"<pre><code class=\"language-unknown\">x\n</code></pre>\n",
+		string(body),
+	)
+
+	cfg = config{HighlightStyle: "github"}
+	body, _ = renderMarkdown([]byte("```go\nfunc\n```\n"), opts)
+	require.Contains(t, string(body), `<span style="color:#000;font-weight:bold">func</span>`)
+
+	opts.highlight = false
+	body, _ = renderMarkdown([]byte("```go {hl_lines=[1]}\nfunc\n```\n"), opts)
+	require.Equal(t, "<pre><code class=\"language-go\">func\n</code></pre>\n", string(body))
+}
+
+func TestValidateHighlight(t *testing.T) {
+	require.NoError(t, config{HighlightStyle: "monokai"}.validateHighlight())
+	require.EqualError(t, config{HighlightStyle: "rainbow"}.validateHighlight(), `unknown highlight style "rainbow"`)
+}
+
+func TestWriteStylesheet(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	cfg = config{HighlightStyle: "monokai"}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeStylesheet(&buf))
+	require.Contains(t, buf.String(), "/* Background */ .bg { color: #f8f8f2; background-color: #272822; }")
+	require.Contains(t, buf.String(), "/* LineHighlight */ .chroma .hl {")
+}
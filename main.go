@@ -9,12 +9,14 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
 	"github.com/BurntSushi/toml"
-	"github.com/caarlos0/env/v6"
 	"github.com/chuhlomin/search"
 	"github.com/disintegration/imaging"
 	i "github.com/nicksnyder/go-i18n/v2/i18n"
@@ -28,23 +30,51 @@ const (
 )
 
 type config struct {
-	BasePath              string   `env:"INPUT_BASE_PATH"`
-	SourceDirectory       string   `env:"INPUT_SOURCE_DIRECTORY" envDefault:"."`
-	StaticDirectory       string   `env:"INPUT_STATIC_DIRECTORY"`
-	OutputDirectory       string   `env:"INPUT_OUTPUT_DIRECTORY" envDefault:"output"`
-	AllowedFileExtensions []string `env:"INPUT_ALLOWED_FILE_EXTENSIONS" envDefault:".jpeg,.jpg,.png,.mp4,.pdf" envSeparator:","`
-	TemplatesDirectory    string   `env:"INPUT_TEMPLATES_DIRECTORY" envDefault:"_templates"`
-	DefaultTemplate       string   `env:"INPUT_DEFAULT_TEMPLATE" envDefault:"_post.html"`
-	DefaultLanguage       string   `env:"INPUT_DEFAULT_LANGUAGE" envDefault:"en"`
-	CommentsEnabled       bool     `env:"INPUT_COMMENTS_ENABLED" envDefault:"true"`
-	CommentsSiteID        string   `env:"INPUT_COMMENTS_SITE_ID" envDefault:""`
-	ShowDrafts            bool     `env:"INPUT_SHOW_DRAFTS"`
-	ThumbPath             string   `env:"INPUT_THUMB_PATH" envDefault:"thumb"`
-	ThumbMaxWidth         int      `env:"INPUT_THUMB_MAX_WIDTH" envDefault:"140"`
-	ThumbMaxHeight        int      `env:"INPUT_THUMB_MAX_HEIGHT" envDefault:"140"`
-	SearchEnabled         bool     `env:"INPUT_SEARCH_ENABLED"`
-	SearchURL             string   `env:"INPUT_SEARCH_URL"`
-	SearchPath            string   `env:"INPUT_SEARCH_PATH" envDefault:"search_index"`
+	BasePath              string   `env:"INPUT_BASE_PATH" toml:"base_path" yaml:"base_path"`
+	SourceDirectory       string   `env:"INPUT_SOURCE_DIRECTORY" envDefault:"." toml:"source_directory" yaml:"source_directory"`
+	StaticDirectory       string   `env:"INPUT_STATIC_DIRECTORY" toml:"static_directory" yaml:"static_directory"`
+	OutputDirectory       string   `env:"INPUT_OUTPUT_DIRECTORY" envDefault:"output" toml:"output_directory" yaml:"output_directory"`
+	AllowedFileExtensions []string `env:"INPUT_ALLOWED_FILE_EXTENSIONS" envDefault:".jpeg,.jpg,.png,.mp4,.pdf" envSeparator:"," toml:"allowed_file_extensions" yaml:"allowed_file_extensions"`
+	TemplatesDirectory    string   `env:"INPUT_TEMPLATES_DIRECTORY" envDefault:"_templates" toml:"templates_directory" yaml:"templates_directory"`
+	DefaultTemplate       string   `env:"INPUT_DEFAULT_TEMPLATE" envDefault:"_post.html" toml:"default_template" yaml:"default_template"`
+	DefaultLanguage       string   `env:"INPUT_DEFAULT_LANGUAGE" envDefault:"en" toml:"default_language" yaml:"default_language"`
+	CommentsEnabled       bool     `env:"INPUT_COMMENTS_ENABLED" envDefault:"false" toml:"comments_enabled" yaml:"comments_enabled"`
+	CommentsSiteID        string   `env:"INPUT_COMMENTS_SITE_ID" envDefault:"" toml:"comments_site_id" yaml:"comments_site_id"`
+	ShowDrafts            bool     `env:"INPUT_SHOW_DRAFTS" toml:"show_drafts" yaml:"show_drafts"`
+	Future                bool     `env:"INPUT_FUTURE" toml:"future" yaml:"future"` // show posts with future publish_date or past expiry_date
+	ThumbPath             string   `env:"INPUT_THUMB_PATH" envDefault:"thumb" toml:"thumb_path" yaml:"thumb_path"`
+	ThumbMaxWidth         int      `env:"INPUT_THUMB_MAX_WIDTH" envDefault:"140" toml:"thumb_max_width" yaml:"thumb_max_width"`
+	ThumbMaxHeight        int      `env:"INPUT_THUMB_MAX_HEIGHT" envDefault:"140" toml:"thumb_max_height" yaml:"thumb_max_height"`
+	SearchEnabled         bool     `env:"INPUT_SEARCH_ENABLED" toml:"search_enabled" yaml:"search_enabled"`
+	SearchURL             string   `env:"INPUT_SEARCH_URL" toml:"search_url" yaml:"search_url"`
+	SearchPath            string   `env:"INPUT_SEARCH_PATH" envDefault:"index.bleve" toml:"search_path" yaml:"search_path"`
+	Incremental           bool     `env:"INPUT_INCREMENTAL" toml:"incremental" yaml:"incremental"`
+	Workers               int      `env:"INPUT_WORKERS" toml:"workers" yaml:"workers"` // 0 means number of CPUs
+	Strict                bool     `env:"INPUT_STRICT" toml:"strict" yaml:"strict"`    // fail the build on content errors
+	Prune                 bool     `env:"INPUT_PRUNE" toml:"prune" yaml:"prune"`
+	PruneDryRun           bool     `env:"INPUT_PRUNE_DRY_RUN" toml:"prune_dry_run" yaml:"prune_dry_run"`
+	PruneKeep             []string `env:"INPUT_PRUNE_KEEP" envSeparator:"," toml:"prune_keep" yaml:"prune_keep"`
+	Report                string   `env:"INPUT_REPORT" toml:"report" yaml:"report"`                                                         // path to JSON build report
+	Redirects             []string `env:"INPUT_REDIRECTS" envSeparator:"," toml:"redirects" yaml:"redirects"`                               // server redirect files to write for aliases: netlify, nginx, caddy
+	SummaryLength         int      `env:"INPUT_SUMMARY_LENGTH" envDefault:"70" toml:"summary_length" yaml:"summary_length"`                 // number of words in post summary without <!--more--> separator
+	WordsPerMinute        int      `env:"INPUT_WORDS_PER_MINUTE" envDefault:"200" toml:"words_per_minute" yaml:"words_per_minute"`          // reading speed to calculate MarkdownFile.ReadingTime
+	PrettyURLs            bool     `env:"INPUT_PRETTY_URLS" toml:"pretty_urls" yaml:"pretty_urls"`                                          // render posts to <name>/index.html and link to <name>/
+	MarkdownExtensions    []string `env:"INPUT_MARKDOWN_EXTENSIONS" envSeparator:"," toml:"markdown_extensions" yaml:"markdown_extensions"` // parser extensions to turn on, or off with "-" prefix
+	MarkdownFlags         []string `env:"INPUT_MARKDOWN_FLAGS" envSeparator:"," toml:"markdown_flags" yaml:"markdown_flags"`                // HTML renderer flags to turn on, or off with "-" prefix
+	Highlight             bool     `env:"INPUT_HIGHLIGHT" toml:"highlight" yaml:"highlight"`                                                // highlight fenced code blocks, see highlightCode
+	HighlightStyle        string   `env:"INPUT_HIGHLIGHT_STYLE" envDefault:"github" toml:"highlight_style" yaml:"highlight_style"`          // chroma style name
+	HighlightClasses      bool     `env:"INPUT_HIGHLIGHT_CLASSES" toml:"highlight_classes" yaml:"highlight_classes"`                        // use CSS classes instead of inline styles, see `genblog stylesheet`
+	HighlightLineNumbers  bool     `env:"INPUT_HIGHLIGHT_LINE_NUMBERS" toml:"highlight_line_numbers" yaml:"highlight_line_numbers"`         // show line numbers, may be changed with linenos fence attribute
+	Typography            bool     `env:"INPUT_TYPOGRAPHY" toml:"typography" yaml:"typography"`                                             // improve quotes, dashes and spaces in posts, see typograph
+	Math                  bool     `env:"INPUT_MATH" toml:"math" yaml:"math"`                                                               // render LaTeX math to MathML, see latexToMathML
+	Timezone              string   `env:"INPUT_TIMEZONE" envDefault:"UTC" toml:"timezone" yaml:"timezone"`                                  // time zone of post dates without zone, e.g. "Europe/Moscow"
+	ConfigFile            string   `env:"INPUT_CONFIG_FILE" toml:"-" yaml:"-"`
+
+	Languages  map[string]languageConfig  `toml:"languages" yaml:"languages"`   // per-language settings, by language code
+	Thumbnails map[string]thumbnailPreset `toml:"thumbnails" yaml:"thumbnails"` // additional thumbnail sizes, by preset name
+	Permalinks map[string]string          `toml:"permalinks" yaml:"permalinks"` // output path patterns, by content type
+
+	timezone *time.Location // Timezone loaded in loadConfig, see location
 }
 
 // GetString returns the value of the environment variable named by the key.
@@ -75,170 +105,410 @@ type Data struct {
 var bundle *i.Bundle
 
 func main() {
-	log.Println("Starting")
-	t := time.Now()
-
-	if err := run(); err != nil {
+	if err := runCommand(os.Args[1:]); err != nil {
 		log.Fatalf("ERROR: %v", err)
 	}
-
-	log.Printf("Finished in %dms", time.Now().Sub(t).Milliseconds())
 }
 
 var ts int64
 
-func run() error {
+func run() (err error) {
 	ts = time.Now().Unix()
-
-	err := env.Parse(&cfg)
-	if err != nil {
-		return errors.Wrap(err, "environment variables parsing")
-	}
-	if cfg.DefaultLanguage == "" {
-		cfg.DefaultLanguage = "en"
+	problems = &problemList{level: "ERROR"}
+	warnings = &problemList{level: "WARNING"}
+	report = newBuildReport()
+
+	if cfg.Report != "" {
+		defer func() {
+			if reportErr := report.write(cfg.Report, err); reportErr != nil && err == nil {
+				err = reportErr
+			}
+		}()
 	}
 
-	lang, err := language.Parse(cfg.DefaultLanguage)
+	bundle, err = newBundle()
 	if err != nil {
-		return errors.Wrapf(err, "parse language %q", cfg.DefaultLanguage)
+		return err
 	}
-	bundle = i.NewBundle(lang) // used in templates/i18n to get translated strings
-	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
 
 	if err = createDirectory(cfg.OutputDirectory); err != nil {
 		return errors.Wrapf(err, "output directory creation %q", cfg.OutputDirectory)
 	}
 
-	t, err := template.New("").Funcs(fm).ParseGlob(cfg.TemplatesDirectory + "/*")
+	t, defaultTemplate, err := parseTemplates()
 	if err != nil {
-		return errors.Wrap(err, "templates parsing")
+		return err
 	}
 
-	defaultTemplate := t.Lookup(cfg.DefaultTemplate)
-	if defaultTemplate == nil {
-		return errors.Errorf("template %q not found", cfg.DefaultTemplate)
+	shortcodes, err = loadShortcodes()
+	if err != nil {
+		return err
 	}
 
-	// scan source directory
-	var markdownFiles []*MarkdownFile
-	tagsCounter := TagsCounterList{}
+	outputs, err = loadManifest(cfg.Incremental)
+	if err != nil {
+		return err
+	}
 
+	// scan source directory
+	start := time.Now()
+	var paths []string
 	channelFiles := make(chan string)
-	channelImages := make(chan image, 100)
 	doneFiles := make(chan bool)
-	doneImages := make(chan bool)
 
 	go func() {
-		for {
-			path, more := <-channelFiles
-			if more {
-				switch filepath.Ext(path) {
-				case ".md":
-					md, err := ParseMarkdownFile(path)
-					if err != nil {
-						log.Printf("ERROR: processing markdown file %s: %v", path, err)
-						continue
-					}
+		for path := range channelFiles {
+			paths = append(paths, path)
+		}
+		doneFiles <- true
+	}()
 
-					if md.Draft && !cfg.ShowDrafts {
-						log.Printf("DEBUG skipping draft %v", path)
-						continue
-					}
+	err = readSourceDirectory(channelFiles)
+	close(channelFiles)
+	<-doneFiles
 
-					for _, image := range md.Images {
-						channelImages <- image
-					}
+	if err != nil {
+		return errors.Wrap(err, "read posts directory")
+	}
 
-					if md.Language == cfg.DefaultLanguage {
-						// count tags only for default language,
-						// assuming that post in different languages have the same tags
-						// and that all posts have a version in default language
-						tagsCounter.Add(md.Tags)
-					}
+	report.stage("scan", start)
+	start = time.Now()
 
-					markdownFiles = append(markdownFiles, md)
+	// Optional .toml files are used to define translations.
+	// They power `i18n` template function.
+	// Loaded one by one, so that the same message ID is always overridden in the same order.
+	translations := map[string]string{} // path -> hash, used to detect changes in incremental builds
+	for _, path := range paths {
+		if filepath.Ext(path) != ".toml" {
+			continue
+		}
 
-				case ".toml":
-					// Optional .toml files are used to define translations.
-					// They power `i18n` template function.
-					_, err := bundle.LoadMessageFile(cfg.SourceDirectory + "/" + path)
-					if err != nil {
-						log.Printf("ERROR load message file %q: %v", cfg.SourceDirectory+"/"+path, err)
-					}
+		if _, err := bundle.LoadMessageFile(cfg.SourceDirectory + "/" + path); err != nil {
+			problems.add(problemTranslation, path, err)
+			continue
+		}
 
-				default:
-					// any other files will be copied to output directory
-					copyFile(
-						cfg.SourceDirectory+"/"+path,
-						cfg.OutputDirectory+"/"+path,
-					)
-				}
-			} else {
-				doneFiles <- true
-				return
-			}
+		translations[path], err = hashFile(cfg.SourceDirectory + "/" + path)
+		if err != nil {
+			problems.add(problemTranslation, path, err)
 		}
-	}()
+	}
+
+	channelImages := make(chan image, 100)
+	doneImages := make(chan struct{}) // closed when all images are processed
 
 	go func() {
-		processedImages := make(map[string]bool)
-
-		for {
-			img, more := <-channelImages
-			if more {
-				if _, ok := processedImages[img.Path]; !ok {
-					if err := resizeImage(
-						cfg.SourceDirectory,
-						img.Path,
-						cfg.OutputDirectory+"/"+img.ThumbPath,
-						cfg.ThumbMaxWidth,
-						cfg.ThumbMaxHeight,
-					); err != nil {
-						log.Printf("ERROR resize image %q: %v", img.Path, err)
-					}
+		start := time.Now()
+
+		var (
+			wg              sync.WaitGroup
+			mu              sync.Mutex // guards processedImages
+			processedImages = make(map[string]bool)
+		)
+
+		for w := 0; w < cfg.workers(); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for img := range channelImages {
+					mu.Lock()
+					processed := processedImages[img.Path]
 					processedImages[img.Path] = true
+					mu.Unlock()
+
+					if processed {
+						continue
+					}
+
+					if err := createThumbnails(img); err != nil {
+						problems.add(problemThumbnail, img.Path, err)
+					}
 				}
-			} else {
-				doneImages <- true
-				return
-			}
+			}()
 		}
+
+		wg.Wait()
+		report.stage("images", start)
+		close(doneImages)
 	}()
 
-	if err := readSourceDirectory(channelFiles); err != nil {
-		return errors.Wrap(err, "read posts directory")
-	}
+	parsed := make([]*MarkdownFile, len(paths)) // same order as paths, nil for skipped files
+	now := time.Unix(ts, 0)
 
-	close(channelFiles)
+	forEach(cfg.workers(), len(paths), func(i int) error {
+		path := paths[i]
+
+		switch filepath.Ext(path) {
+		case ".md":
+			md, err := ParseMarkdownFile(path)
+			if err != nil {
+				problems.add(problemMarkdown, path, err)
+				return nil
+			}
+
+			if md.Draft && !cfg.ShowDrafts {
+				log.Printf("DEBUG skipping draft %v", path)
+				atomic.AddInt64(&report.DraftsSkipped, 1)
+				return nil
+			}
+
+			if md.scheduled(now) && !cfg.Future {
+				log.Printf("DEBUG skipping scheduled post %v, publish date %v", path, md.PublishDate)
+				atomic.AddInt64(&report.ScheduledSkipped, 1)
+				return nil
+			}
+
+			if md.expired(now) && !cfg.Future {
+				log.Printf("DEBUG skipping expired post %v, expiry date %v", path, md.ExpiryDate)
+				atomic.AddInt64(&report.ExpiredSkipped, 1)
+				return nil
+			}
+
+			for _, image := range md.Images {
+				channelImages <- image
+			}
+
+			parsed[i] = md
+
+		case ".toml":
+			// already loaded
+
+		default:
+			// any other files will be copied to output directory
+			if err := copyFileIfChanged(
+				cfg.SourceDirectory+"/"+path,
+				cfg.OutputDirectory+"/"+path,
+			); err != nil {
+				problems.add(problemCopy, path, err)
+			}
+		}
+
+		return nil
+	})
 
-	<-doneFiles
 	close(channelImages)
+	// errors below return early, images must be processed before the next build starts
+	defer func() { <-doneImages }()
+
+	var markdownFiles []*MarkdownFile
+	tagsCounter := TagsCounterList{}
+	sources := map[string]string{} // output path -> source path
+
+	for _, md := range parsed {
+		if md == nil {
+			continue
+		}
+
+		if source, ok := sources[md.Path]; ok {
+			problems.add(problemMarkdown, md.Source, errors.Errorf("path %q is already used by %q", md.Path, source))
+			continue
+		}
+		sources[md.Path] = md.Source
+
+		if md.Language == cfg.DefaultLanguage {
+			// count tags only for default language,
+			// assuming that post in different languages have the same tags
+			// and that all posts have a version in default language
+			tagsCounter.Add(md.Tags)
+		}
+
+		markdownFiles = append(markdownFiles, md)
+	}
 
 	sort.Sort(ByCreated(markdownFiles))
+	resolveLinks(markdownFiles)
+
+	report.stage("parse", start)
+	report.Tags = tagsCounter
+
+	hash, err := siteHash(markdownFiles, translations)
+	if err != nil {
+		return errors.Wrap(err, "site hash calculation")
+	}
 
 	log.Println("Rendering markdown files...")
-	if err = renderMarkdownFiles(markdownFiles, defaultTemplate); err != nil {
+	start = time.Now()
+	if err = renderMarkdownFiles(markdownFiles, defaultTemplate, hash); err != nil {
 		return errors.Wrap(err, "rendering pages")
 	}
+	report.stage("render", start)
 
+	start = time.Now()
 	if err := copyFiles(cfg.StaticDirectory, cfg.OutputDirectory); err != nil {
 		return errors.Wrap(err, "copy static files")
 	}
+	report.stage("static", start)
 
 	printTagsStags(tagsCounter)
 
 	log.Println("Rendering templates...")
-	if err := renderTemplates(t, markdownFiles); err != nil {
+	start = time.Now()
+	if err := renderTemplates(t, markdownFiles, hash); err != nil {
 		return errors.Wrap(err, "rendering templates")
 	}
+	report.stage("templates", start)
+
+	start = time.Now()
+	if err := writeRedirects(aliases(markdownFiles)); err != nil {
+		return errors.Wrap(err, "writing redirects")
+	}
+	report.stage("redirects", start)
 
 	if cfg.SearchEnabled {
+		start = time.Now()
 		if err := createSearchIndex(markdownFiles, cfg.SearchPath); err != nil {
 			return errors.Wrap(err, "search index creation")
 		}
+		report.stage("search", start)
 	}
 
 	<-doneImages
+
+	if cfg.Strict && problems.len() > 0 {
+		// the output is left as is, but it's not pruned and the manifest is not saved,
+		// so that the next build starts from the same state
+		log.Printf("Problems found:\n%s", problems.summary())
+		return errors.Errorf("strict mode: found %d problem(s)", problems.len())
+	}
+
+	if cfg.Prune || cfg.PruneDryRun {
+		log.Println("Pruning output directory...")
+		start = time.Now()
+		if _, err := pruneOutputDirectory(outputs, cfg.PruneDryRun); err != nil {
+			return errors.Wrap(err, "prune output directory")
+		}
+		report.stage("prune", start)
+	}
+
+	if cfg.Incremental {
+		if err := outputs.save(); err != nil {
+			return errors.Wrap(err, "save manifest")
+		}
+	}
+
+	return nil
+}
+
+// newBundle creates i18n bundle for cfg.DefaultLanguage,
+// it's used in templates/i18n to get translated strings
+func newBundle() (*i.Bundle, error) {
+	lang, err := language.Parse(cfg.DefaultLanguage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse language %q", cfg.DefaultLanguage)
+	}
+	b := i.NewBundle(lang)
+	b.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+	return b, nil
+}
+
+// parseTemplates parses all templates in cfg.TemplatesDirectory
+// and returns them along with the default template
+func parseTemplates() (*template.Template, *template.Template, error) {
+	files, err := templateFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, nil, errors.Errorf("no templates found in %q", cfg.TemplatesDirectory)
+	}
+
+	t, err := template.New("").Funcs(fm).ParseFiles(files...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "templates parsing")
+	}
+
+	defaultTemplate := t.Lookup(cfg.DefaultTemplate)
+	if defaultTemplate == nil {
+		return nil, nil, errors.Errorf("template %q not found", cfg.DefaultTemplate)
+	}
+
+	return t, defaultTemplate, nil
+}
+
+// templateFiles returns paths to files in cfg.TemplatesDirectory,
+// skipping subdirectories, e.g. archetypes
+func templateFiles() ([]string, error) {
+	paths, err := filepath.Glob(cfg.TemplatesDirectory + "/*")
+	if err != nil {
+		return nil, errors.Wrap(err, "templates listing")
+	}
+
+	var files []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// check parses templates, markdown and translation files in the source directory
+// and reports all problems found, without writing anything to the output directory
+func check() error {
+	var err error
+	bundle, err = newBundle()
+	if err != nil {
+		return err
+	}
+
+	t, _, err := parseTemplates()
+	if err != nil {
+		return err
+	}
+
+	shortcodes, err = loadShortcodes()
+	if err != nil {
+		return err
+	}
+
+	problems = &problemList{level: "ERROR"}
+
+	var (
+		files   int
+		walkErr error
+	)
+
+	paths := make(chan string)
+	go func() {
+		walkErr = readSourceDirectory(paths)
+		close(paths)
+	}()
+
+	for path := range paths {
+		files++
+
+		switch filepath.Ext(path) {
+		case ".md":
+			md, err := ParseMarkdownFile(path)
+			if err != nil {
+				problems.add(problemMarkdown, path, err)
+				continue
+			}
+
+			if md.Template != "" && t.Lookup(md.Template) == nil {
+				problems.add(problemTemplate, path, errors.Errorf("template %q not found", md.Template))
+			}
+
+		case ".toml":
+			if _, err := bundle.LoadMessageFile(cfg.SourceDirectory + "/" + path); err != nil {
+				problems.add(problemTranslation, path, err)
+			}
+		}
+	}
+
+	if walkErr != nil {
+		return errors.Wrap(walkErr, "read posts directory")
+	}
+
+	log.Printf("Checked %d files", files)
+
+	if problems.len() > 0 {
+		log.Printf("Problems found:\n%s", problems.summary())
+		return errors.Errorf("found %d problem(s)", problems.len())
+	}
+
 	return nil
 }
 
@@ -253,6 +523,61 @@ func getImageFromURL(url string) (goimage.Image, error) {
 	return img, err
 }
 
+// createThumbnails creates the default thumbnail of the image
+// and thumbnails for every preset in cfg.Thumbnails
+func createThumbnails(img image) error {
+	if err := createThumbnail(img.Path, img.ThumbPath, cfg.ThumbMaxWidth, cfg.ThumbMaxHeight); err != nil {
+		return err
+	}
+
+	presets := make([]string, 0, len(img.Thumbs))
+	for name := range img.Thumbs {
+		presets = append(presets, name)
+	}
+	sort.Strings(presets)
+
+	for _, name := range presets {
+		preset := cfg.Thumbnails[name]
+		if err := createThumbnail(img.Path, img.Thumbs[name], preset.MaxWidth, preset.MaxHeight); err != nil {
+			return errors.Wrapf(err, "thumbnail preset %q", name)
+		}
+	}
+
+	return nil
+}
+
+// createThumbnail resizes the image unless its thumbnail is up to date
+func createThumbnail(path, thumbPath string, maxWidth, maxHeight int) error {
+	written, err := outputs.build(
+		cfg.OutputDirectory+"/"+thumbPath,
+		func() (string, error) {
+			source := path // remote images are not downloaded to check if they changed
+			if !isValidURL(path) {
+				var err error
+				if source, err = hashFile(cfg.SourceDirectory + "/" + path); err != nil {
+					return "", err
+				}
+			}
+			return hashStrings(source, strconv.Itoa(maxWidth), strconv.Itoa(maxHeight)), nil
+		},
+		func() error {
+			return resizeImage(
+				cfg.SourceDirectory,
+				path,
+				cfg.OutputDirectory+"/"+thumbPath,
+				maxWidth,
+				maxHeight,
+			)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	count(written, &report.ThumbnailsCreated, &report.ThumbnailsUpToDate)
+	return nil
+}
+
 func resizeImage(srcDir, path, thumbPath string, maxWidth, maxHeight int) error {
 	var (
 		img goimage.Image
@@ -289,8 +614,11 @@ func resizeImage(srcDir, path, thumbPath string, maxWidth, maxHeight int) error
 	return nil
 }
 
-func renderMarkdownFiles(files []*MarkdownFile, defaultTmpl *template.Template) error {
-	for _, file := range files {
+// renderMarkdownFiles renders every markdown file into cfg.OutputDirectory,
+// hash is used to skip pages that are up to date in incremental builds
+func renderMarkdownFiles(files []*MarkdownFile, defaultTmpl *template.Template, hash string) error {
+	return forEach(cfg.workers(), len(files), func(i int) error {
+		file := files[i]
 		tmpl := defaultTmpl
 		if file.Template != "" {
 			tmpl = defaultTmpl.Lookup(file.Template)
@@ -299,7 +627,7 @@ func renderMarkdownFiles(files []*MarkdownFile, defaultTmpl *template.Template)
 			}
 		}
 
-		if err := renderTemplate(
+		if err := renderPage(
 			cfg.OutputDirectory+"/"+file.Path,
 			Data{
 				Current:   file,
@@ -307,14 +635,15 @@ func renderMarkdownFiles(files []*MarkdownFile, defaultTmpl *template.Template)
 				Timestamp: ts,
 			},
 			tmpl,
+			hash,
 		); err != nil {
 			return errors.Wrapf(err, "rendering page %q", file.Path)
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
-func renderTemplates(t *template.Template, files []*MarkdownFile) error {
+func renderTemplates(t *template.Template, files []*MarkdownFile, hash string) error {
 	mapID := make(map[string][]*MarkdownFile)
 
 	// group pages by ID
@@ -341,34 +670,34 @@ func renderTemplates(t *template.Template, files []*MarkdownFile) error {
 		})
 	}
 
-	for _, pages := range mapID {
-		sort.Sort(ByLanguage(pages))
+	var pages []Data
+	for _, variations := range mapID {
+		sort.Sort(ByLanguage(variations))
 
-		for _, p := range pages {
+		for _, p := range variations {
+			pages = append(pages, Data{
+				Current:            p,
+				All:                files,
+				LanguageVariations: variations,
+				Timestamp:          ts,
+			})
+		}
+	}
 
-			tmpl := t.Lookup(p.Path)
-			if tmpl == nil {
-				log.Printf("WARNING: template %q not found", p.Path)
-				continue
-			}
+	return forEach(cfg.workers(), len(pages), func(i int) error {
+		data := pages[i]
 
-			err := renderTemplate(
-				cfg.OutputDirectory+"/"+p.Path,
-				Data{
-					Current:            p,
-					All:                files,
-					LanguageVariations: pages,
-					Timestamp:          ts,
-				},
-				tmpl,
-			)
-			if err != nil {
-				return errors.Wrapf(err, "write template %q", p.Path)
-			}
+		tmpl := t.Lookup(data.Current.Path)
+		if tmpl == nil {
+			warnings.add(problemTemplate, data.Current.Path, errors.New("template not found"))
+			return nil
 		}
-	}
 
-	return nil
+		if err := renderPage(cfg.OutputDirectory+"/"+data.Current.Path, data, tmpl, hash); err != nil {
+			return errors.Wrapf(err, "write template %q", data.Current.Path)
+		}
+		return nil
+	})
 }
 
 func copyFiles(from, to string) error {
@@ -377,7 +706,7 @@ func copyFiles(from, to string) error {
 	}
 
 	if _, err := os.Stat(from); os.IsNotExist(err) {
-		log.Printf("WARNING: directory %q not found", from)
+		warnings.add(problemStatic, from, errors.New("directory not found"))
 		return nil
 	}
 
@@ -402,7 +731,7 @@ func copyFiles(from, to string) error {
 			return err
 		}
 
-		if err := copyFile(path, to+"/"+relPath); err != nil {
+		if err := copyFileIfChanged(path, to+"/"+relPath); err != nil {
 			return err
 		}
 
@@ -410,6 +739,36 @@ func copyFiles(from, to string) error {
 	})
 }
 
+// renderPage renders the template unless the page is up to date
+func renderPage(filename string, data Data, t *template.Template, hash string) error {
+	written, err := outputs.build(
+		filename,
+		func() (string, error) { return hash, nil },
+		func() error { return renderTemplate(filename, data, t) },
+	)
+	if err != nil {
+		return err
+	}
+
+	count(written, &report.PagesRendered, &report.PagesUpToDate)
+	return nil
+}
+
+// copyFileIfChanged copies the file unless its copy is up to date
+func copyFileIfChanged(src, dst string) error {
+	written, err := outputs.build(
+		dst,
+		func() (string, error) { return hashFile(src) },
+		func() error { return copyFile(src, dst) },
+	)
+	if err != nil {
+		return err
+	}
+
+	count(written, &report.FilesCopied, &report.FilesUpToDate)
+	return nil
+}
+
 func copyFile(src, dst string) error {
 	dir := filepath.Dir(dst)
 	if err := os.MkdirAll(dir, permDir); err != nil {
@@ -462,14 +821,15 @@ func readSourceDirectory(filesChannel chan string) error {
 
 		if strings.HasPrefix(path, cfg.OutputDirectory) ||
 			strings.HasPrefix(path, ".git") ||
-			(len(cfg.StaticDirectory) > 0 && strings.HasPrefix(path, cfg.StaticDirectory)) {
+			(len(cfg.StaticDirectory) > 0 && strings.HasPrefix(path, cfg.StaticDirectory)) ||
+			strings.HasPrefix(path, filepath.Join(cfg.TemplatesDirectory, archetypesDirectory)) {
 			return nil
 		}
 
 		ext := filepath.Ext(path)
 
 		if (ext == ".md" && path != "README.md") ||
-			ext == ".toml" ||
+			(ext == ".toml" && !isConfigFile(path)) ||
 			inArray(cfg.AllowedFileExtensions, ext) {
 
 			filesChannel <- path
@@ -490,7 +850,7 @@ func inArray(s []string, needle string) bool {
 func createSearchIndex(pagesData []*MarkdownFile, searchIndexPath string) error {
 	// check if search index exists, if not create it
 	if _, err := os.Stat(searchIndexPath); !os.IsNotExist(err) {
-		log.Println("Search index alrady exists, skipping creation")
+		warnings.add(problemSearch, searchIndexPath, errors.New("search index already exists, skipping creation"))
 		return nil
 	}
 
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSearchIndex(t *testing.T) {
+	defer func() { cfg = config{} }()
+	cfg = config{DefaultLanguage: "en"}
+
+	var posts []*MarkdownFile
+	for source, content := range map[string]string{
+		"2022/winter.md": "---\ndate: 2022-01-15\n---\n# Winter\n\nSnow",
+		"2022/summer.md": "---\ndate: 2022-07-15\n---\n# Summer\n\nSun",
+	} {
+		md, err := processMarkdownFileContent(source, []byte(content))
+		require.NoError(t, err)
+		posts = append(posts, md)
+	}
+
+	path := filepath.Join(t.TempDir(), "index")
+	require.NoError(t, createSearchIndex(posts, path))
+
+	index, err := bleve.Open(path)
+	require.NoError(t, err)
+	defer index.Close()
+
+	// Date is indexed as before, DateISO is an additional field
+	for _, field := range []string{"Date", "DateISO"} {
+		query := bleve.NewDateRangeQuery(
+			time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+		)
+		query.SetField(field)
+
+		result, err := index.Search(bleve.NewSearchRequest(query))
+		require.NoError(t, err, field)
+		require.Equal(t, uint64(1), result.Total, field)
+		require.Equal(t, "2022/summer.md", result.Hits[0].ID, field)
+
+		request := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+		request.SortBy([]string{field})
+		result, err = index.Search(request)
+		require.NoError(t, err, field)
+		require.Equal(t, "2022/winter.md", result.Hits[0].ID, field)
+		require.Equal(t, "2022/summer.md", result.Hits[1].ID, field)
+	}
+}
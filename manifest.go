@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// manifestFile is stored in cfg.OutputDirectory and keeps hashes of inputs
+// of every generated file, so that incremental builds can skip outputs
+// that are up to date
+const manifestFile = ".genblog-manifest.json"
+
+// manifest tracks files written to cfg.OutputDirectory during the build
+type manifest struct {
+	incremental bool
+	mu          sync.Mutex
+	previous    map[string]string // output path -> inputs hash, from the previous build
+	current     map[string]string // output path -> inputs hash, from the current build
+}
+
+// outputs is a manifest of the current build, it's created in `run`
+var outputs *manifest
+
+// loadManifest reads the manifest of the previous build from cfg.OutputDirectory.
+// If incremental builds are disabled, the previous manifest is ignored
+// and every output is written again.
+func loadManifest(incremental bool) (*manifest, error) {
+	m := &manifest{
+		incremental: incremental,
+		previous:    map[string]string{},
+		current:     map[string]string{},
+	}
+
+	if !incremental {
+		return m, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(cfg.OutputDirectory, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil // first build
+		}
+		return nil, errors.Wrap(err, "read manifest")
+	}
+
+	if err := json.Unmarshal(b, &m.previous); err != nil {
+		return nil, errors.Wrap(err, "parse manifest")
+	}
+
+	return m, nil
+}
+
+// save writes manifest of the current build to cfg.OutputDirectory
+func (m *manifest) save() error {
+	b, err := json.MarshalIndent(m.current, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal manifest")
+	}
+
+	return ioutil.WriteFile(filepath.Join(cfg.OutputDirectory, manifestFile), b, permFile)
+}
+
+// build calls write to create the output file, unless it was built
+// by the previous build from the inputs with the same hash.
+// The inputs hash is calculated only when incremental builds are enabled.
+// It returns true if the file was written.
+func (m *manifest) build(output string, inputs func() (string, error), write func() error) (bool, error) {
+	key := m.key(output)
+
+	hash := ""
+	if m.incremental {
+		var err error
+		if hash, err = inputs(); err != nil {
+			return false, errors.Wrapf(err, "hash inputs of %q", key)
+		}
+
+		m.mu.Lock()
+		prev, ok := m.previous[key]
+		m.mu.Unlock()
+
+		if ok && prev == hash {
+			if _, err := os.Stat(output); err == nil {
+				m.record(key, hash)
+				return false, nil
+			}
+		}
+	}
+
+	if err := write(); err != nil {
+		return false, err
+	}
+
+	m.record(key, hash)
+	return true, nil
+}
+
+func (m *manifest) record(key, hash string) {
+	m.mu.Lock()
+	m.current[key] = hash
+	m.mu.Unlock()
+}
+
+// recorded returns true if the output was recorded by the current build,
+// key is the output path relative to cfg.OutputDirectory
+func (m *manifest) recorded(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.current[key]
+	return ok
+}
+
+// key returns output path relative to cfg.OutputDirectory
+func (m *manifest) key(output string) string {
+	rel, err := filepath.Rel(cfg.OutputDirectory, output)
+	if err != nil {
+		return filepath.ToSlash(output)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// hashFile returns sha1 of the file content
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashStrings returns sha1 of the given strings
+func hashStrings(parts ...string) string {
+	h := sha1.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// siteHash returns hash of everything that every rendered page depends on:
+// config, templates, translation files and the data of all pages.
+// If any of them change, all pages are rendered again.
+func siteHash(files []*MarkdownFile, translations map[string]string) (string, error) {
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal config")
+	}
+
+	filesJSON, err := json.Marshal(files)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal pages")
+	}
+
+	parts := []string{string(cfgJSON), string(filesJSON)}
+
+	templates, err := templateFiles()
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range templates {
+		hash, err := hashFile(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "hash template %q", path)
+		}
+		parts = append(parts, path, hash)
+	}
+
+	paths := make([]string, 0, len(translations))
+	for path := range translations {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		parts = append(parts, path, translations[path])
+	}
+
+	return hashStrings(parts...), nil
+}
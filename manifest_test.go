@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestBuild(t *testing.T) {
+	cfg = config{OutputDirectory: t.TempDir()}
+	output := filepath.Join(cfg.OutputDirectory, "post.html")
+
+	writes := 0
+	write := func() error {
+		writes++
+		return ioutil.WriteFile(output, []byte("post"), permFile)
+	}
+	inputs := func(hash string) func() (string, error) {
+		return func() (string, error) { return hash, nil }
+	}
+
+	// first build writes the file
+	m, err := loadManifest(true)
+	require.NoError(t, err)
+	_, err = m.build(output, inputs("a"), write)
+	require.NoError(t, err)
+	require.NoError(t, m.save())
+	require.Equal(t, 1, writes)
+
+	// same inputs, the file is skipped but still recorded
+	m, err = loadManifest(true)
+	require.NoError(t, err)
+	_, err = m.build(output, inputs("a"), write)
+	require.NoError(t, err)
+	require.Equal(t, 1, writes)
+	require.Equal(t, map[string]string{"post.html": "a"}, m.current)
+	require.NoError(t, m.save())
+
+	// inputs changed, the file is written again
+	m, err = loadManifest(true)
+	require.NoError(t, err)
+	_, err = m.build(output, inputs("b"), write)
+	require.NoError(t, err)
+	require.Equal(t, 2, writes)
+
+	// incremental builds disabled, the file is always written
+	m, err = loadManifest(false)
+	require.NoError(t, err)
+	_, err = m.build(output, inputs("b"), write)
+	require.NoError(t, err)
+	require.Equal(t, 3, writes)
+}
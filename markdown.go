@@ -11,10 +11,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gomarkdown/markdown"
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v3"
 )
 
 // MarkdownFile represents a markdown file, for example
@@ -24,32 +24,51 @@ import (
 //    # Title
 //    Page content
 type MarkdownFile struct {
-	Source          string  `yaml:"-"`                          // path to the source markdown file
-	Path            string  `yaml:"-"`                          // path to the generated HTML file
-	Canonical       string  `yaml:"-"`                          // canonical URL
-	ID              string  `yaml:"-"`                          // same post in different languages will have the same ID value
-	Markdown        string  `yaml:"-" indexer:"text"`           // content of the markdown file
-	Title           string  `yaml:"title" indexer:"text"`       // by default equals to H1 in Markdown file
-	Body            string  `yaml:"-" indexer:"no_store"`       // html body, generated from markdown
-	Date            string  `yaml:"date" indexer:"date"`        // date when post was published, in format "2006-01-02"
-	ContentType     string  `yaml:"type"`                       // "post" (by default), "page", etc.
-	Tags            tags    `yaml:"tags"`                       // post tags, by default parsed from the post
-	Language        string  `yaml:"language"`                   // language ("en", "ru", ...), parsed from filename, overrides config.DefaultLanguage
-	Draft           bool    `yaml:"draft"`                      // draft is used to mark post as draft
-	Template        string  `yaml:"template"`                   // template to use in config.TemplatesDirectory, overrides default "post.html"
-	Order           string  `yaml:"order"`                      // can be used to sort pages
-	CommentsEnabled *bool   `yaml:"comments_enabled"`           // comments_enabled overrides config.CommentsEnabled
-	Description     string  `yaml:"description" indexer:"text"` // description is used for the meta description
-	Author          string  `yaml:"author"`                     // author is used for the meta author
-	Keywords        string  `yaml:"keywords"`                   // keywords is used for the meta keywords
-	Image           string  `yaml:"image"`                      // image associated with the post; it's used to generate the thumbnailPath
-	Images          []image `yaml:"-"`                          // images in the post
+	Source          string   `yaml:"-"`                          // path to the source markdown file
+	Path            string   `yaml:"-"`                          // path to the generated HTML file
+	Canonical       string   `yaml:"-"`                          // canonical URL
+	ID              string   `yaml:"-"`                          // same post in different languages will have the same ID value
+	Markdown        string   `yaml:"-" indexer:"text"`           // content of the markdown file
+	Title           string   `yaml:"title" indexer:"text"`       // by default equals to H1 in Markdown file
+	Body            string   `yaml:"-" indexer:"no_store"`       // html body, generated from markdown
+	Summary         string   `yaml:"-"`                          // html summary, the part before <!--more--> or first config.SummaryLength words
+	Truncated       bool     `yaml:"-"`                          // true if Summary is shorter than Body
+	WordCount       int      `yaml:"-"`                          // number of words in Body
+	ReadingTime     int      `yaml:"-"`                          // reading time in minutes, see config.wordsPerMinute
+	Date            date     `yaml:"date"`                       // date when post was published, see dateLayouts
+	DateISO         string   `yaml:"-" indexer:"date"`           // Date in RFC 3339 format, the search index only maps strings as dates
+	ContentType     string   `yaml:"type"`                       // "post" (by default), "page", etc.
+	Tags            tags     `yaml:"tags"`                       // post tags, by default parsed from the post
+	Language        string   `yaml:"language"`                   // language ("en", "ru", ...), parsed from filename, overrides config.DefaultLanguage
+	Draft           bool     `yaml:"draft"`                      // draft is used to mark post as draft
+	PublishDate     date     `yaml:"publish_date"`               // post is not published before this date, unless config.Future is set
+	ExpiryDate      date     `yaml:"expiry_date"`                // post is not published after this date, unless config.Future is set
+	Template        string   `yaml:"template"`                   // template to use in config.TemplatesDirectory, overrides default "post.html"
+	Slug            string   `yaml:"slug"`                       // used in the output path instead of the file name, see config.permalink
+	Aliases         []string `yaml:"aliases"`                    // old URL paths of the post, redirected to the post
+	Order           string   `yaml:"order"`                      // can be used to sort pages
+	CommentsEnabled *bool    `yaml:"comments_enabled"`           // comments_enabled overrides config.CommentsEnabled
+	Description     string   `yaml:"description" indexer:"text"` // description is used for the meta description
+	Author          string   `yaml:"author"`                     // author is used for the meta author
+	Keywords        string   `yaml:"keywords"`                   // keywords is used for the meta keywords
+	Image           string   `yaml:"image"`                      // image associated with the post; it's used to generate the thumbnailPath
+	Images          []image  `yaml:"-"`                          // images in the post
+
+	TOC         []*tocEntry `yaml:"-"`             // table of contents, headings between TOCMinLevel and TOCMaxLevel
+	TOCHTML     string      `yaml:"-"`             // table of contents as nested HTML lists
+	TOCMinLevel int         `yaml:"toc_min_level"` // the highest level of headings in TOC, 2 by default
+	TOCMaxLevel int         `yaml:"toc_max_level"` // the lowest level of headings in TOC, 3 by default
+
+	MarkdownExtensions []string               `yaml:"markdown_extensions"` // overrides config.MarkdownExtensions, see markdownOptions.apply
+	MarkdownFlags      []string               `yaml:"markdown_flags"`      // overrides config.MarkdownFlags
+	Sidenotes          bool                   `yaml:"sidenotes"`           // footnotes are rendered as sidenotes, see footnotes
+	Params             map[string]interface{} `yaml:"-"`                   // other metadata keys, see param template functions
 }
 
 type ByCreated []*MarkdownFile
 
 func (md ByCreated) Len() int           { return len(md) }
-func (md ByCreated) Less(i, j int) bool { return md[i].Date > md[j].Date }
+func (md ByCreated) Less(i, j int) bool { return md[i].Date.After(md[j].Date.Time) }
 func (md ByCreated) Swap(i, j int)      { md[i], md[j] = md[j], md[i] }
 
 type ByOrder []*MarkdownFile
@@ -66,16 +85,25 @@ func (md ByLanguage) Swap(i, j int)      { md[i], md[j] = md[j], md[i] }
 
 // image is a struct that contains metadata of image from the post
 type image struct {
-	Path      string `yaml:"path"`
-	Alt       string `yaml:"alt"`
-	Title     string `yaml:"title"`
-	ThumbPath string `yaml:"thumb_path"`
-	Promo     bool   `yaml:"promo"`
+	Path      string            `yaml:"path"`
+	Alt       string            `yaml:"alt"`
+	Title     string            `yaml:"title"`
+	ThumbPath string            `yaml:"thumb_path"`
+	Thumbs    map[string]string `yaml:"thumbs"` // thumbnail preset name -> path, see config.Thumbnails
+	Promo     bool              `yaml:"promo"`
 }
 
 type tags []string
 
+// UnmarshalYAML accepts both comma-separated string "tagA, tagB"
+// and a list of tags
 func (t *tags) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*t = list
+		return nil
+	}
+
 	var s string
 	if err := unmarshal(&s); err != nil {
 		return err
@@ -93,6 +121,16 @@ func (md MarkdownFile) Type() string {
 	return md.ContentType + suffix
 }
 
+// scheduled reports whether the post has publish date in the future
+func (md MarkdownFile) scheduled(now time.Time) bool {
+	return !md.PublishDate.IsZero() && md.PublishDate.After(now)
+}
+
+// expired reports whether the post has expiry date in the past
+func (md MarkdownFile) expired(now time.Time) bool {
+	return !md.ExpiryDate.IsZero() && !md.ExpiryDate.After(now)
+}
+
 func ParseMarkdownFile(path string) (*MarkdownFile, error) {
 	content, err := ioutil.ReadFile(cfg.SourceDirectory + "/" + path)
 	if err != nil {
@@ -103,14 +141,12 @@ func ParseMarkdownFile(path string) (*MarkdownFile, error) {
 }
 
 func processMarkdownFileContent(path string, content []byte) (*MarkdownFile, error) {
-	outputPath := strings.Replace(path, ".md", ".html", 1)
-
 	md := &MarkdownFile{
 		Source:      path,
-		Path:        outputPath,
-		Canonical:   langToGetParameter(outputPath),
 		Tags:        tags([]string{}), // setting default value, so that there is no need to check for nil in templates
 		ContentType: "post",           // default value, may be overridden by metadata
+		TOCMinLevel: defaultTOCMinLevel,
+		TOCMaxLevel: defaultTOCMaxLevel,
 	}
 
 	md.ID, md.Language = getIDAndLangFromFilename(path)
@@ -119,33 +155,71 @@ func processMarkdownFileContent(path string, content []byte) (*MarkdownFile, err
 	}
 	md.Language = strings.ToLower(md.Language)
 
+	bodyBytes, err := md.processContent(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to process markdown file")
+	}
+
+	if !md.Date.IsZero() {
+		md.DateISO = md.Date.Format(time.RFC3339)
+	}
+
 	if md.CommentsEnabled == nil {
-		md.CommentsEnabled = &cfg.CommentsEnabled
+		md.CommentsEnabled = cfg.commentsEnabled(md.Language)
 	}
 
-	bodyBytes, err := md.processContent(content)
+	md.Path, err = cfg.permalink(md)
+	if err != nil {
+		return nil, err
+	}
+	md.Canonical = langToGetParameter(md.Path)
+
+	opts, err := cfg.markdownOptions(md)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to process markdown file")
 	}
 
 	md.Markdown = string(bodyBytes)
+	markdownBody := bodyBytes
 
-	bodyBytes = markdown.ToHTML(bodyBytes, nil, nil)
-
-	// todo: add typograph here :typograph.NewTypograph().Process(bodyBytes)
+	bodyBytes, headings := renderMarkdown(bodyBytes, opts)
 
 	md.Body = string(bodyBytes)
+	md.TOC = buildTOC(headings, md.TOCMinLevel, md.TOCMaxLevel)
+	md.Summary, md.Truncated = summary(markdownBody, md.Body, cfg.SummaryLength, opts)
+	md.WordCount = countWords(md.Body)
+	md.ReadingTime = readingTime(md.WordCount, cfg.wordsPerMinute(md.Language))
+
+	if cfg.typography(md.Language) {
+		md.Body = typograph(md.Body, md.Language)
+		md.Summary = typograph(md.Summary, md.Language)
+		typographTOC(md.TOC, md.Language)
+	}
+
+	md.TOCHTML = renderTOC(md.TOC)
 
 	return md, nil
 }
 
 func (md *MarkdownFile) processContent(content []byte) ([]byte, error) {
-	metadataBytes, bodyBytes := splitMetadataAndBody(content)
+	format, metadataBytes, bodyBytes, err := splitMetadataAndBody(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing metadata")
+	}
 
-	if err := md.parseMetadata(metadataBytes); err != nil {
+	if err := md.parseMetadata(format, metadataBytes); err != nil {
 		return nil, errors.Wrapf(err, "parsing metadata")
 	}
 
+	if !md.PublishDate.IsZero() && !md.ExpiryDate.IsZero() && !md.ExpiryDate.After(md.PublishDate.Time) {
+		return nil, errors.Errorf("expiry_date %s is not after publish_date %s", md.ExpiryDate, md.PublishDate)
+	}
+
+	bodyBytes, err = md.expandShortcodes(bodyBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "expanding shortcodes")
+	}
+
 	baseDir := filepath.Dir(md.Source)
 	relativePath := baseDir
 	thumbPath := cfg.ThumbPath + "/" + baseDir
@@ -161,19 +235,29 @@ func (md *MarkdownFile) processContent(content []byte) ([]byte, error) {
 
 	bodyBytes = md.processBody(bodyBytes, relativePath, thumbPath)
 
+	for i := range md.Images {
+		md.Images[i].Thumbs = cfg.thumbnailPaths(md.Images[i].ThumbPath)
+	}
+
 	return bodyBytes, nil
 }
 
-// parseMetadata parses YAML metadata at the beginning of the Markdown file.
+// parseMetadata parses YAML, TOML or JSON metadata at the beginning of the Markdown file.
 // For convinience, it puts all metadata into MarkdownFile struct.
-func (md *MarkdownFile) parseMetadata(b []byte) error {
+func (md *MarkdownFile) parseMetadata(format string, b []byte) error {
 	if len(b) == 0 {
 		return nil
 	}
 
-	if err := yaml.Unmarshal(b, &md); err != nil {
+	if err := unmarshalFrontMatter(format, b, md); err != nil {
+		return errors.Wrapf(err, "reading metadata")
+	}
+
+	var values map[string]interface{}
+	if err := unmarshalFrontMatter(format, b, &values); err != nil {
 		return errors.Wrapf(err, "reading metadata")
 	}
+	md.Params = parseParams(values)
 
 	return nil
 }
@@ -182,7 +266,6 @@ var (
 	imageMarkdown  = regexp.MustCompile(`!\[(.*?)\]\(([^\s)]*)\s*"?([^"]*?)?"?\)`)
 	imageHTML      = regexp.MustCompile(`<img(.*?)>`)
 	htmlAttributes = regexp.MustCompile(`(\S+)\s*=\s*\"?(.*?)\"`)
-	linkToMD       = regexp.MustCompile(`\[(.+?)\]\((.+?).md\)`)
 )
 
 // processBody parses Title, Tags and Images from Markdown file content.
@@ -259,16 +342,6 @@ func (md *MarkdownFile) processBody(b []byte, relativePath, thumbPath string) []
 			}
 		}
 
-		// parse links to markdown files
-		// replace links to markdown files with links to HTML files
-		if matches := linkToMD.FindAllStringSubmatch(line, -1); matches != nil {
-			for _, match := range matches {
-				path := langToGetParameter(match[2] + ".html")
-				line = strings.Replace(line, match[0], "["+match[1]+"]("+path+")", -1)
-				b = []byte(line)
-			}
-		}
-
 		buf.Write(b)
 		buf.WriteString("\n")
 	}
@@ -276,16 +349,6 @@ func (md *MarkdownFile) processBody(b []byte, relativePath, thumbPath string) []
 	return buf.Bytes()
 }
 
-func splitMetadataAndBody(b []byte) ([]byte, []byte) {
-	if bytes.HasPrefix(b, []byte("---")) {
-		if parts := bytes.SplitN(b, []byte("---"), 3); len(parts) == 3 {
-			return parts[1], parts[2]
-		}
-	}
-
-	return []byte{}, b
-}
-
 func isValidURL(toTest string) bool {
 	_, err := url.ParseRequestURI(toTest)
 	if err != nil {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -31,11 +32,16 @@ func TestProcess(t *testing.T) {
 				Markdown:        "Post body\n",
 				Title:           "Blogpost",
 				Body:            "<p>Post body</p>\n",
-				Date:            "2006-01-02",
+				Summary:         "<p>Post body</p>\n",
+				WordCount:       2,
+				Date:            date{Time: time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC), layout: "2006-01-02"},
+				DateISO:         "2006-01-02T00:00:00Z",
 				Language:        "",
 				ContentType:     "post",
 				Tags:            []string{},
 				CommentsEnabled: boolPtr(false),
+				TOCMinLevel:     defaultTOCMinLevel,
+				TOCMaxLevel:     defaultTOCMaxLevel,
 			},
 		},
 		{
@@ -53,11 +59,16 @@ func TestProcess(t *testing.T) {
 				Markdown:        "Post body\n",
 				Title:           "Blogpost",
 				Body:            "<p>Post body</p>\n",
-				Date:            "2006-01-02",
+				Summary:         "<p>Post body</p>\n",
+				WordCount:       2,
+				Date:            date{Time: time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC), layout: "2006-01-02"},
+				DateISO:         "2006-01-02T00:00:00Z",
 				Language:        "ru",
 				ContentType:     "post",
 				Tags:            []string{},
 				CommentsEnabled: boolPtr(false),
+				TOCMinLevel:     defaultTOCMinLevel,
+				TOCMaxLevel:     defaultTOCMaxLevel,
 			},
 		},
 		{
@@ -73,11 +84,13 @@ func TestProcess(t *testing.T) {
 				Markdown:        "",
 				Title:           "",
 				Body:            "",
-				Date:            "",
+				Date:            date{},
 				Language:        "",
 				ContentType:     "post",
 				Tags:            []string{},
 				CommentsEnabled: boolPtr(false),
+				TOCMinLevel:     defaultTOCMinLevel,
+				TOCMaxLevel:     defaultTOCMaxLevel,
 			},
 		},
 	}
@@ -345,3 +358,56 @@ func TestProcessImages(t *testing.T) {
 		require.Equal(t, test.images, md.Images, test.desc)
 	}
 }
+
+func TestProcessPublishAndExpiryDates(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc      string
+		content   []byte
+		scheduled bool
+		expired   bool
+		err       string
+	}{
+		{
+			desc:    "No publish and expiry dates",
+			content: []byte("---\ndate: 2022-01-02\n---\n"),
+		},
+		{
+			desc:    "Publish date in the past",
+			content: []byte("---\npublish_date: 2022-06-01 11:59\n---\n"),
+		},
+		{
+			desc:      "Publish date in the future",
+			content:   []byte("---\npublish_date: 2022-06-01 12:01\n---\n"),
+			scheduled: true,
+		},
+		{
+			desc:    "Expiry date in the future",
+			content: []byte("---\nexpiry_date: 2022-06-02\n---\n"),
+		},
+		{
+			desc:    "Expiry date in the past",
+			content: []byte("---\nexpiry_date: 2022-06-01\n---\n"),
+			expired: true,
+		},
+		{
+			desc:    "Expiry date before publish date",
+			content: []byte("---\npublish_date: 2022-06-02\nexpiry_date: 2022-06-01\n---\n"),
+			err:     "failed to process markdown file: expiry_date 2022-06-01 is not after publish_date 2022-06-02",
+		},
+	}
+
+	for _, test := range tests {
+		cfg = config{}
+		md, err := processMarkdownFileContent("2022/post.md", test.content)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.scheduled, md.scheduled(now), test.desc)
+		require.Equal(t, test.expired, md.expired(now), test.desc)
+	}
+}
@@ -0,0 +1,720 @@
+package main
+
+import (
+	"html"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/pkg/errors"
+)
+
+// mathSymbols are MathML elements of LaTeX commands without arguments
+var mathSymbols = func() map[string]string {
+	symbols := map[string]string{}
+
+	for _, s := range []string{
+		"alpha α", "beta β", "gamma γ", "delta δ", "epsilon ϵ", "varepsilon ε", "zeta ζ", "eta η",
+		"theta θ", "vartheta ϑ", "iota ι", "kappa κ", "lambda λ", "mu μ", "nu ν", "xi ξ", "pi π",
+		"varpi ϖ", "rho ρ", "varrho ϱ", "sigma σ", "varsigma ς", "tau τ", "upsilon υ", "phi ϕ",
+		"varphi φ", "chi χ", "psi ψ", "omega ω", "infty ∞", "partial ∂", "nabla ∇", "ell ℓ",
+		"hbar ℏ", "emptyset ∅", "varnothing ∅", "aleph ℵ", "Re ℜ", "Im ℑ",
+	} {
+		name, text := split2(s)
+		symbols[name] = "<mi>" + text + "</mi>"
+	}
+
+	// upright in LaTeX, single characters in <mi> are italic
+	for _, s := range []string{
+		"Gamma Γ", "Delta Δ", "Theta Θ", "Lambda Λ", "Xi Ξ", "Pi Π", "Sigma Σ", "Upsilon Υ",
+		"Phi Φ", "Psi Ψ", "Omega Ω",
+	} {
+		name, text := split2(s)
+		symbols[name] = `<mi mathvariant="normal">` + text + "</mi>"
+	}
+
+	for _, s := range []string{
+		"pm ±", "mp ∓", "times ×", "div ÷", "cdot ⋅", "ast ∗", "star ⋆", "circ ∘", "bullet ∙",
+		"le ≤", "leq ≤", "ge ≥", "geq ≥", "ne ≠", "neq ≠", "approx ≈", "equiv ≡", "sim ∼",
+		"simeq ≃", "cong ≅", "propto ∝", "ll ≪", "gg ≫", "in ∈", "notin ∉", "ni ∋", "subset ⊂",
+		"supset ⊃", "subseteq ⊆", "supseteq ⊇", "cup ∪", "cap ∩", "setminus ∖", "wedge ∧",
+		"land ∧", "vee ∨", "lor ∨", "neg ¬", "lnot ¬", "forall ∀", "exists ∃", "oplus ⊕",
+		"otimes ⊗", "to →", "rightarrow →", "leftarrow ←", "gets ←", "leftrightarrow ↔",
+		"Rightarrow ⇒", "Leftarrow ⇐", "Leftrightarrow ⇔", "iff ⟺", "implies ⟹", "mapsto ↦",
+		"uparrow ↑", "downarrow ↓", "mid ∣", "parallel ∥", "perp ⊥", "angle ∠", "ldots …",
+		"dots …", "cdots ⋯", "vdots ⋮", "ddots ⋱", "langle ⟨", "rangle ⟩", "lfloor ⌊",
+		"rfloor ⌋", "lceil ⌈", "rceil ⌉", "vert |", "Vert ‖", "| ‖", "colon :", "prime ′",
+		"sum ∑", "prod ∏", "coprod ∐", "bigcup ⋃", "bigcap ⋂", "bigoplus ⨁", "bigotimes ⨂",
+		"int ∫", "iint ∬", "iiint ∭", "oint ∮", "{ {", "} }", "% %", "$ $", "# #", "& &amp;", "_ _",
+	} {
+		name, text := split2(s)
+		symbols[name] = "<mo>" + text + "</mo>"
+	}
+
+	for _, name := range strings.Fields(
+		"sin cos tan cot sec csc arcsin arccos arctan sinh cosh tanh coth log ln lg exp " +
+			"deg dim ker arg hom gcd Pr lim liminf limsup max min sup inf det",
+	) {
+		symbols[name] = "<mi>" + name + "</mi>"
+	}
+
+	return symbols
+}()
+
+var (
+	// mathLimits are operators with sub- and superscripts under and over them
+	mathLimits = map[string]bool{
+		"sum": true, "prod": true, "coprod": true, "bigcup": true, "bigcap": true, "bigoplus": true,
+		"bigotimes": true, "lim": true, "liminf": true, "limsup": true, "max": true, "min": true,
+		"sup": true, "inf": true, "det": true, "gcd": true, "Pr": true,
+	}
+	// mathSpaces are widths of spacing commands
+	mathSpaces = map[string]string{
+		",": "0.1667em", ":": "0.2222em", ">": "0.2222em", ";": "0.2778em", "!": "-0.1667em",
+		" ": "0.3333em", "quad": "1em", "qquad": "2em",
+	}
+	// mathAccents are characters over the argument, e.g. \hat{x}
+	mathAccents = map[string]string{
+		"hat": "^", "widehat": "^", "bar": "‾", "overline": "‾", "vec": "→", "dot": "˙",
+		"ddot": "¨", "tilde": "~", "widetilde": "~", "overrightarrow": "→",
+	}
+	// mathVariants are mathvariant attributes of font commands
+	mathVariants = map[string]string{
+		"mathrm": "normal", "mathbf": "bold", "mathit": "italic", "mathbb": "double-struck",
+		"mathcal": "script", "mathfrak": "fraktur", "mathsf": "sans-serif", "mathtt": "monospace",
+		"boldsymbol": "bold-italic",
+	}
+	// mathDelimiters are commands allowed after \left and \right
+	mathDelimiters = map[string]string{
+		"{": "{", "}": "}", "|": "‖", "langle": "⟨", "rangle": "⟩", "lvert": "|", "rvert": "|",
+		"vert": "|", "Vert": "‖", "lVert": "‖", "rVert": "‖", "lfloor": "⌊", "rfloor": "⌋",
+		"lceil": "⌈", "rceil": "⌉",
+	}
+)
+
+// mathEnvironment is a matrix-like environment, \begin{name} ... \end{name}
+type mathEnvironment struct {
+	open, close string // fences around the table
+	align       string // columnalign attribute of the table
+}
+
+var mathEnvironments = map[string]mathEnvironment{
+	"matrix":   {},
+	"array":    {},
+	"pmatrix":  {open: "(", close: ")"},
+	"bmatrix":  {open: "[", close: "]"},
+	"Bmatrix":  {open: "{", close: "}"},
+	"vmatrix":  {open: "|", close: "|"},
+	"Vmatrix":  {open: "‖", close: "‖"},
+	"cases":    {open: "{", align: "left"},
+	"aligned":  {align: "right left"},
+	"align":    {align: "right left"},
+	"align*":   {align: "right left"},
+	"gathered": {},
+}
+
+// renderMath is a renderer hook for math parsed by the mathjax extension,
+// math is rendered to MathML. Math that can't be converted is rendered as is
+// and reported in warnings of the source file.
+func renderMath(renderer *mdhtml.Renderer, w io.Writer, node ast.Node, entering bool, source string) (ast.WalkStatus, bool) {
+	var (
+		tex     string
+		display bool
+	)
+
+	switch node := node.(type) {
+	case *ast.Math:
+		tex = string(node.Literal)
+	case *ast.MathBlock:
+		tex, display = string(node.Literal), true
+	default:
+		return ast.GoToNext, false
+	}
+
+	mathML, err := latexToMathML(tex, display)
+	if err != nil {
+		if entering {
+			warnings.add(problemMarkdown, source, errors.Wrapf(err, "math %q", strings.TrimSpace(tex)))
+		}
+		return ast.GoToNext, false
+	}
+
+	if !entering {
+		return ast.GoToNext, true
+	}
+
+	if display {
+		return renderer.RenderNode(w, &ast.HTMLBlock{Leaf: ast.Leaf{Literal: []byte(mathML + "\n")}}, true), true
+	}
+
+	io.WriteString(w, mathML)
+	return ast.GoToNext, true
+}
+
+// latexToMathML converts LaTeX math to MathML, display math is a block.
+// It supports a subset of LaTeX: symbols, scripts, fractions, roots, fonts, text,
+// accents, \left and \right delimiters and matrix environments.
+func latexToMathML(tex string, display bool) (string, error) {
+	row, err := parseMath(tex)
+	if err != nil {
+		return "", err
+	}
+
+	if row == "" {
+		return "", errors.New("empty math")
+	}
+
+	if display {
+		return `<math display="block">` + row + "</math>", nil
+	}
+	return "<math>" + row + "</math>", nil
+}
+
+// parseMath returns MathML elements of the LaTeX math
+func parseMath(tex string) (string, error) {
+	p := &mathParser{src: tex}
+
+	items, err := p.parseExpr()
+	if err != nil {
+		return "", err
+	}
+
+	if p.pos < len(p.src) {
+		return "", errors.Errorf("unexpected %q", p.token())
+	}
+
+	return strings.Join(items, ""), nil
+}
+
+// mathParser is a recursive descent parser of LaTeX math
+type mathParser struct {
+	src string
+	pos int
+}
+
+// mathAtom is a MathML element that can have sub- and superscripts
+type mathAtom struct {
+	mathML string
+	limits bool // scripts are under and over it, e.g. \sum
+}
+
+// parseExpr parses elements until the end of the group: "}", "&", "\\", \right or \end,
+// which are left unread
+func (p *mathParser) parseExpr() ([]string, error) {
+	var items []string
+
+	for {
+		p.skipSpace()
+		if p.atEnd() {
+			return items, nil
+		}
+
+		item, err := p.parseScripted()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// parseScripted parses an atom with sub- and superscripts, e.g. x_i^2 or f'
+func (p *mathParser) parseScripted() (string, error) {
+	base := mathAtom{mathML: "<mrow></mrow>"}
+	if c := p.peek(); c != '^' && c != '_' {
+		var err error
+		if base, err = p.parseAtom(false); err != nil {
+			return "", err
+		}
+	}
+
+	var (
+		sub, sup string
+		primes   []string
+	)
+	for {
+		p.skipSpace()
+
+		c := p.peek()
+		if c == '\'' {
+			p.pos++
+			primes = append(primes, "<mo>′</mo>")
+			continue
+		}
+
+		if c != '^' && c != '_' {
+			break
+		}
+		p.pos++
+
+		arg, err := p.parseArgument(string(c))
+		if err != nil {
+			return "", err
+		}
+
+		if c == '^' {
+			if sup != "" {
+				return "", errors.New("double superscript")
+			}
+			sup = arg
+		} else {
+			if sub != "" {
+				return "", errors.New("double subscript")
+			}
+			sub = arg
+		}
+	}
+
+	if primes != nil {
+		if sup != "" {
+			primes = append(primes, sup)
+		}
+		sup = mathRow(primes)
+	}
+
+	under, over := "msub", "msup"
+	both := "msubsup"
+	if base.limits {
+		under, over, both = "munder", "mover", "munderover"
+	}
+
+	switch {
+	case sub != "" && sup != "":
+		return "<" + both + ">" + base.mathML + sub + sup + "</" + both + ">", nil
+	case sub != "":
+		return "<" + under + ">" + base.mathML + sub + "</" + under + ">", nil
+	case sup != "":
+		return "<" + over + ">" + base.mathML + sup + "</" + over + ">", nil
+	}
+	return base.mathML, nil
+}
+
+// parseAtom parses a group, a command, a number or a character.
+// With single, numbers are one digit long, as in arguments: \frac12
+func (p *mathParser) parseAtom(single bool) (mathAtom, error) {
+	c := p.src[p.pos]
+
+	switch {
+	case c == '{':
+		p.pos++
+		items, err := p.parseExpr()
+		if err != nil {
+			return mathAtom{}, err
+		}
+		if p.peek() != '}' {
+			return mathAtom{}, errors.New(`missing "}"`)
+		}
+		p.pos++
+		return mathAtom{mathML: mathRow(items)}, nil
+
+	case c == '\\':
+		return p.parseCommand()
+
+	case isDigit(c):
+		start := p.pos
+		p.pos++
+		for !single && p.pos < len(p.src) && (isDigit(p.src[p.pos]) ||
+			p.src[p.pos] == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1])) {
+			p.pos++
+		}
+		return mathAtom{mathML: "<mn>" + p.src[start:p.pos] + "</mn>"}, nil
+	}
+
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+
+	switch {
+	case unicode.IsLetter(r):
+		return mathAtom{mathML: "<mi>" + string(r) + "</mi>"}, nil
+	case r == '~':
+		return mathAtom{mathML: "<mtext>\u00a0</mtext>"}, nil
+	case r == '-':
+		return mathAtom{mathML: "<mo>−</mo>"}, nil
+	case r == '*':
+		return mathAtom{mathML: "<mo>∗</mo>"}, nil
+	case r == '\'':
+		return mathAtom{mathML: "<mo>′</mo>"}, nil
+	case r == '#' || r == '%' || r == '$':
+		return mathAtom{}, errors.Errorf("unexpected %q", string(r))
+	}
+	return mathAtom{mathML: "<mo>" + html.EscapeString(string(r)) + "</mo>"}, nil
+}
+
+// parseCommand parses a command with its arguments, e.g. \frac{1}{2}
+func (p *mathParser) parseCommand() (mathAtom, error) {
+	name := p.readCommand()
+	command := `\` + name
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "binom":
+		num, err := p.parseArgument(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		den, err := p.parseArgument(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		if name == "binom" {
+			return mathAtom{mathML: `<mrow><mo>(</mo><mfrac linethickness="0">` + num + den + "</mfrac><mo>)</mo></mrow>"}, nil
+		}
+		return mathAtom{mathML: "<mfrac>" + num + den + "</mfrac>"}, nil
+
+	case "sqrt":
+		index, hasIndex, err := p.readOptional(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		arg, err := p.parseArgument(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		if !hasIndex {
+			return mathAtom{mathML: "<msqrt>" + arg + "</msqrt>"}, nil
+		}
+		index, err = parseMath(index)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		return mathAtom{mathML: "<mroot>" + arg + "<mrow>" + index + "</mrow></mroot>"}, nil
+
+	case "text", "textrm", "mbox":
+		text, err := p.readGroup(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		return mathAtom{mathML: "<mtext>" + html.EscapeString(text) + "</mtext>"}, nil
+
+	case "operatorname":
+		text, err := p.readGroup(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		return mathAtom{mathML: "<mi>" + html.EscapeString(strings.TrimSpace(text)) + "</mi>"}, nil
+
+	case "underline":
+		arg, err := p.parseArgument(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		return mathAtom{mathML: `<munder accentunder="true">` + arg + "<mo>_</mo></munder>"}, nil
+
+	case "left":
+		return p.parseFenced()
+
+	case "begin":
+		return p.parseEnvironment()
+	}
+
+	if variant, ok := mathVariants[name]; ok {
+		arg, err := p.parseArgument(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		return mathAtom{mathML: `<mstyle mathvariant="` + variant + `">` + arg + "</mstyle>"}, nil
+	}
+
+	if accent, ok := mathAccents[name]; ok {
+		arg, err := p.parseArgument(command)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		return mathAtom{mathML: `<mover accent="true">` + arg + "<mo>" + accent + "</mo></mover>"}, nil
+	}
+
+	if width, ok := mathSpaces[name]; ok {
+		return mathAtom{mathML: `<mspace width="` + width + `"/>`}, nil
+	}
+
+	if symbol, ok := mathSymbols[name]; ok {
+		return mathAtom{mathML: symbol, limits: mathLimits[name]}, nil
+	}
+
+	return mathAtom{}, errors.Errorf("unknown command %q", command)
+}
+
+// parseArgument parses an argument of the command: a group or a single atom
+func (p *mathParser) parseArgument(command string) (string, error) {
+	p.skipSpace()
+	if p.atEnd() || p.peek() == '^' || p.peek() == '_' {
+		return "", errors.Errorf("missing argument of %q", command)
+	}
+
+	atom, err := p.parseAtom(true)
+	return atom.mathML, err
+}
+
+// parseFenced parses \left( ... \right), \left has been read
+func (p *mathParser) parseFenced() (mathAtom, error) {
+	open, err := p.readDelimiter(`\left`)
+	if err != nil {
+		return mathAtom{}, err
+	}
+
+	items, err := p.parseExpr()
+	if err != nil {
+		return mathAtom{}, err
+	}
+
+	if p.peekCommand() != "right" {
+		return mathAtom{}, errors.New(`missing "\right"`)
+	}
+	p.readCommand()
+
+	close, err := p.readDelimiter(`\right`)
+	if err != nil {
+		return mathAtom{}, err
+	}
+
+	return mathAtom{mathML: "<mrow>" + open + strings.Join(items, "") + close + "</mrow>"}, nil
+}
+
+// parseEnvironment parses a matrix-like environment, \begin has been read
+func (p *mathParser) parseEnvironment() (mathAtom, error) {
+	name, err := p.readGroup(`\begin`)
+	if err != nil {
+		return mathAtom{}, err
+	}
+
+	env, ok := mathEnvironments[name]
+	if !ok {
+		return mathAtom{}, errors.Errorf("unknown environment %q", name)
+	}
+
+	if name == "array" {
+		// column specification is ignored
+		if _, err := p.readGroup(`\begin{array}`); err != nil {
+			return mathAtom{}, err
+		}
+	}
+
+	var rows, cells []string
+	for {
+		items, err := p.parseExpr()
+		if err != nil {
+			return mathAtom{}, err
+		}
+		cells = append(cells, "<mtd>"+strings.Join(items, "")+"</mtd>")
+
+		if p.peek() == '&' {
+			p.pos++
+			continue
+		}
+
+		switch p.peekCommand() {
+		case `\`:
+			p.readCommand()
+			rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+			cells = nil
+			continue
+		case "end":
+			p.readCommand()
+		default:
+			return mathAtom{}, errors.Errorf(`missing "\end{%s}"`, name)
+		}
+
+		end, err := p.readGroup(`\end`)
+		if err != nil {
+			return mathAtom{}, err
+		}
+		if end != name {
+			return mathAtom{}, errors.Errorf(`"\begin{%s}" ended by "\end{%s}"`, name, end)
+		}
+
+		// a row break before \end doesn't add a row
+		if len(cells) > 1 || cells[0] != "<mtd></mtd>" {
+			rows = append(rows, "<mtr>"+strings.Join(cells, "")+"</mtr>")
+		}
+		return mathAtom{mathML: env.table(rows)}, nil
+	}
+}
+
+// table returns MathML table of the environment with the rows
+func (env mathEnvironment) table(rows []string) string {
+	table := "<mtable>"
+	if env.align != "" {
+		table = `<mtable columnalign="` + env.align + `">`
+	}
+	table += strings.Join(rows, "") + "</mtable>"
+
+	if env.open == "" && env.close == "" {
+		return table
+	}
+	return "<mrow>" + mathFence(env.open) + table + mathFence(env.close) + "</mrow>"
+}
+
+// readDelimiter reads a delimiter after \left or \right, "." is no delimiter
+func (p *mathParser) readDelimiter(command string) (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return "", errors.Errorf("missing delimiter after %q", command)
+	}
+
+	if p.src[p.pos] == '\\' {
+		name := p.readCommand()
+		if d, ok := mathDelimiters[name]; ok {
+			return mathFence(d), nil
+		}
+		return "", errors.Errorf(`unknown delimiter "\%s"`, name)
+	}
+
+	c := p.src[p.pos]
+	p.pos++
+
+	switch c {
+	case '.':
+		return "", nil
+	case '(', ')', '[', ']', '|', '/':
+		return mathFence(string(c)), nil
+	}
+	return "", errors.Errorf("unknown delimiter %q", string(c))
+}
+
+// readGroup returns the content of the {...} argument as is
+func (p *mathParser) readGroup(command string) (string, error) {
+	p.skipSpace()
+	if p.peek() != '{' {
+		return "", errors.Errorf("missing argument of %q", command)
+	}
+
+	depth := 0
+	for i := p.pos; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++ // escaped character, e.g. \}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				group := p.src[p.pos+1 : i]
+				p.pos = i + 1
+				return group, nil
+			}
+		}
+	}
+
+	return "", errors.New(`missing "}"`)
+}
+
+// readOptional returns the content of the optional [...] argument as is
+func (p *mathParser) readOptional(command string) (string, bool, error) {
+	p.skipSpace()
+	if p.peek() != '[' {
+		return "", false, nil
+	}
+
+	depth := 0
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				arg := p.src[p.pos+1 : i]
+				p.pos = i + 1
+				return arg, true, nil
+			}
+		}
+	}
+
+	return "", false, errors.Errorf(`missing "]" in %q`, command)
+}
+
+// readCommand reads the name of the command after a backslash:
+// letters or a single character, e.g. "frac" or ","
+func (p *mathParser) readCommand() string {
+	name := p.peekCommand()
+	p.pos += 1 + len(name)
+	return name
+}
+
+// peekCommand returns the name of the next command, or "" if the next token isn't a command
+func (p *mathParser) peekCommand() string {
+	if p.peek() != '\\' || p.pos+1 >= len(p.src) {
+		return ""
+	}
+
+	end := p.pos + 1
+	for end < len(p.src) && isASCIILetter(p.src[end]) {
+		end++
+	}
+
+	if end == p.pos+1 {
+		_, size := utf8.DecodeRuneInString(p.src[end:])
+		end += size
+	}
+
+	return p.src[p.pos+1 : end]
+}
+
+// atEnd reports whether the current group ends
+func (p *mathParser) atEnd() bool {
+	switch p.peek() {
+	case 0, '}', '&':
+		return true
+	case '\\':
+		name := p.peekCommand()
+		return name == `\` || name == "right" || name == "end"
+	}
+	return false
+}
+
+// token returns the next token for error messages
+func (p *mathParser) token() string {
+	if name := p.peekCommand(); name != "" {
+		return `\` + name
+	}
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	return p.src[p.pos : p.pos+size]
+}
+
+// peek returns the next byte, 0 at the end
+func (p *mathParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *mathParser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// mathRow groups elements in <mrow>, unless there is only one
+func mathRow(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return "<mrow>" + strings.Join(items, "") + "</mrow>"
+}
+
+// mathFence returns the stretchy delimiter, "" for no delimiter
+func mathFence(d string) string {
+	if d == "" {
+		return ""
+	}
+	return `<mo fence="true" stretchy="true">` + html.EscapeString(d) + "</mo>"
+}
+
+// split2 splits "name text" into name and text
+func split2(s string) (string, string) {
+	i := strings.IndexByte(s, ' ')
+	return s[:i], s[i+1:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatexToMathML(t *testing.T) {
+	tests := []struct {
+		desc     string
+		tex      string
+		display  bool
+		expected string
+		err      string
+	}{
+		{
+			desc:     "Identifiers, numbers and operators",
+			tex:      `a + 2.5b - c \le \alpha`,
+			expected: "<math><mi>a</mi><mo>+</mo><mn>2.5</mn><mi>b</mi><mo>−</mo><mi>c</mi><mo>≤</mo><mi>α</mi></math>",
+		},
+		{
+			desc:     "Scripts and primes",
+			tex:      `x_i^2 + f''(x) + {}^{14}C`,
+			expected: "<math><msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup><mo>+</mo><msup><mi>f</mi><mrow><mo>′</mo><mo>′</mo></mrow></msup><mo>(</mo><mi>x</mi><mo>)</mo><mo>+</mo><msup><mrow></mrow><mn>14</mn></msup><mi>C</mi></math>",
+		},
+		{
+			desc:     "Fractions, roots and limits",
+			tex:      `\sum_{n=1}^\infty \frac12 \sqrt[3]{x} \sqrt{y}`,
+			display:  true,
+			expected: `<math display="block"><munderover><mo>∑</mo><mrow><mi>n</mi><mo>=</mo><mn>1</mn></mrow><mi>∞</mi></munderover><mfrac><mn>1</mn><mn>2</mn></mfrac><mroot><mi>x</mi><mrow><mn>3</mn></mrow></mroot><msqrt><mi>y</mi></msqrt></math>`,
+		},
+		{
+			desc:     "Text, fonts, accents and spaces",
+			tex:      `\mathbb{R} \, \text{if } \hat{x} < \operatorname{rank}`,
+			expected: `<math><mstyle mathvariant="double-struck"><mi>R</mi></mstyle><mspace width="0.1667em"/><mtext>if </mtext><mover accent="true"><mi>x</mi><mo>^</mo></mover><mo>&lt;</mo><mi>rank</mi></math>`,
+		},
+		{
+			desc:     "Delimiters",
+			tex:      `\left\{ x \right.`,
+			expected: `<math><mrow><mo fence="true" stretchy="true">{</mo><mi>x</mi></mrow></math>`,
+		},
+		{
+			desc:     "Matrix",
+			tex:      `\begin{pmatrix} 1 & 0 \\ 0 & 1 \\ \end{pmatrix}`,
+			expected: `<math><mrow><mo fence="true" stretchy="true">(</mo><mtable><mtr><mtd><mn>1</mn></mtd><mtd><mn>0</mn></mtd></mtr><mtr><mtd><mn>0</mn></mtd><mtd><mn>1</mn></mtd></mtr></mtable><mo fence="true" stretchy="true">)</mo></mrow></math>`,
+		},
+		{
+			desc: "Unknown command",
+			tex:  `\foo x`,
+			err:  `unknown command "\\foo"`,
+		},
+		{
+			desc: "Missing argument",
+			tex:  `\frac{1}`,
+			err:  `missing argument of "\\frac"`,
+		},
+		{
+			desc: "Unbalanced braces",
+			tex:  `{x`,
+			err:  `missing "}"`,
+		},
+		{
+			desc: "Unexpected closing brace",
+			tex:  `x}`,
+			err:  `unexpected "}"`,
+		},
+		{
+			desc: "Double superscript",
+			tex:  `x^2^3`,
+			err:  "double superscript",
+		},
+		{
+			desc: "Environment mismatch",
+			tex:  `\begin{matrix} x \end{pmatrix}`,
+			err:  `"\begin{matrix}" ended by "\end{pmatrix}"`,
+		},
+		{
+			desc: "Missing right delimiter",
+			tex:  `\left( x`,
+			err:  `missing "\right"`,
+		},
+	}
+
+	for _, test := range tests {
+		mathML, err := latexToMathML(test.tex, test.display)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.expected, mathML, test.desc)
+	}
+}
+
+func TestRenderMath(t *testing.T) {
+	defer func() { warnings = &problemList{level: "WARNING"} }()
+	warnings = &problemList{level: "WARNING"}
+
+	opts := defaultMarkdownOptions
+	opts.math = true
+	opts.source = "post.md"
+
+	body, _ := renderMarkdown([]byte("Inline $x^2$ and $\\foo$.\n\n$$\n\\frac{a}{b}\n$$\n\nText\n"), opts)
+	require.Equal(
+		t,
+		"<p>Inline <math><msup><mi>x</mi><mn>2</mn></msup></math>"+
+			" and <span class=\"math inline\">\\(\\foo\\)</span>.</p>\n\n"+
+			"<math display=\"block\"><mfrac><mi>a</mi><mi>b</mi></mfrac></math>\n\n"+
+			"<p>Text</p>\n",
+		string(body),
+	)
+	require.Equal(
+		t,
+		[]problem{{Kind: problemMarkdown, Source: "post.md", Message: `math "\\foo": unknown command "\\foo"`}},
+		warnings.sorted(),
+	)
+
+	opts.math = false
+	body, _ = renderMarkdown([]byte("$x$\n"), opts)
+	require.Equal(t, "<p><span class=\"math inline\">\\(x\\)</span></p>\n", string(body))
+}
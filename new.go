@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// archetypesDirectory is a subdirectory of cfg.TemplatesDirectory
+// with templates of new posts, one per content type: post.md, page.md, note.md.
+// Translations may have their own archetypes, e.g. post_ru.md.
+const archetypesDirectory = "archetypes"
+
+// defaultArchetype is used when there is no archetype for the content type
+const defaultArchetype = `---
+title: {{ quote .Title }}
+date: {{ .Date }}
+{{- if .Tags }}
+tags: {{ join .Tags ", " }}
+{{- end }}
+{{- if ne .Type "post" }}
+type: {{ .Type }}
+{{- end }}
+draft: true
+---
+
+`
+
+// archetypeData is passed to archetype templates
+type archetypeData struct {
+	Title    string
+	Date     string // in format "2006-01-02"
+	Tags     []string
+	Type     string // content type, "post", "page", "note", ...
+	Language string
+}
+
+var archetypeFuncs = template.FuncMap{
+	"join":  join,
+	"quote": quote,
+}
+
+// quote returns the string as double-quoted YAML scalar
+func quote(s string) string {
+	b, _ := json.Marshal(s) // JSON strings are valid YAML strings
+	return string(b)
+}
+
+// createPost creates a new draft post in cfg.SourceDirectory from the archetype
+// of the content type. If withTranslations is true, it also creates translations
+// for every language in cfg.Languages, e.g. post_ru.md.
+// It never overwrites existing files.
+func createPost(path string, data archetypeData, withTranslations bool) error {
+	if filepath.Ext(path) != ".md" {
+		path += ".md"
+	}
+
+	if data.Type == "" {
+		data.Type = "post"
+	}
+
+	files := map[string]string{path: cfg.DefaultLanguage} // path -> language
+
+	if withTranslations {
+		languages := translationLanguages()
+		if len(languages) == 0 {
+			return errors.New("no languages configured besides the default one, see `languages` in config file")
+		}
+
+		base := strings.TrimSuffix(path, ".md")
+		for _, lang := range languages {
+			files[base+"_"+lang+".md"] = lang
+		}
+	}
+
+	// check all files first, so that nothing is created if any of them exist
+	for file := range files {
+		if _, err := os.Stat(filepath.Join(cfg.SourceDirectory, file)); err == nil {
+			return errors.Errorf("file %q already exists", file)
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+
+	for _, file := range paths {
+		data.Language = files[file]
+
+		content, err := renderArchetype(data)
+		if err != nil {
+			return err
+		}
+
+		fullPath := filepath.Join(cfg.SourceDirectory, file)
+		if err := createDirectory(filepath.Dir(fullPath)); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(fullPath, content, permFile); err != nil {
+			return errors.Wrapf(err, "write file %q", fullPath)
+		}
+
+		log.Printf("Created %s", fullPath)
+	}
+
+	return nil
+}
+
+// translationLanguages returns sorted languages from cfg.Languages,
+// except the default one
+func translationLanguages() []string {
+	var languages []string
+	for lang := range cfg.Languages {
+		if lang != cfg.DefaultLanguage {
+			languages = append(languages, lang)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// renderArchetype renders the first archetype found for the content type and language:
+// <type>_<lang>.md, <type>.md, default.md in archetypesDirectory, or defaultArchetype
+func renderArchetype(data archetypeData) ([]byte, error) {
+	text := defaultArchetype
+
+	dir := filepath.Join(cfg.TemplatesDirectory, archetypesDirectory)
+	candidates := []string{
+		data.Type + "_" + data.Language + ".md",
+		data.Type + ".md",
+		"default.md",
+	}
+
+	for _, name := range candidates {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			text = string(b)
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "read archetype %q", name)
+		}
+	}
+
+	t, err := template.New("archetype").Funcs(archetypeFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse archetype")
+	}
+
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, errors.Wrap(err, "render archetype")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// newArchetypeData returns archetype data with the current date
+func newArchetypeData(title, tags, contentType string, date time.Time) archetypeData {
+	data := archetypeData{
+		Title: title,
+		Date:  date.Format("2006-01-02"),
+		Type:  contentType,
+	}
+
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			data.Tags = append(data.Tags, tag)
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePost(t *testing.T) {
+	dir := t.TempDir()
+	cfg = config{
+		SourceDirectory:    dir,
+		TemplatesDirectory: filepath.Join(dir, "_templates"),
+		DefaultLanguage:    "en",
+		Languages: map[string]languageConfig{
+			"en": {Name: "English"},
+			"ru": {Name: "Русский"},
+		},
+	}
+
+	// archetype only for the Russian translation of notes
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.TemplatesDirectory, archetypesDirectory), permDir))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(cfg.TemplatesDirectory, archetypesDirectory, "note_ru.md"),
+		[]byte("---\ndate: {{ .Date }}\ntype: {{ .Type }}\n---\n\n# {{ .Title }}\n"),
+		permFile,
+	))
+
+	data := newArchetypeData(`Say "hello"`, "go, cli,", "note", time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, createPost("2022/hello", data, true))
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "2022/hello.md"))
+	require.NoError(t, err)
+	require.Equal(t, "---\ntitle: \"Say \\\"hello\\\"\"\ndate: 2022-01-02\ntags: go, cli\ntype: note\ndraft: true\n---\n\n", string(b))
+
+	md, err := processMarkdownFileContent("2022/hello.md", b)
+	require.NoError(t, err)
+	require.Equal(t, `Say "hello"`, md.Title)
+	require.Equal(t, tags{"go", "cli"}, md.Tags)
+	require.Equal(t, "note", md.ContentType)
+	require.True(t, md.Draft)
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "2022/hello_ru.md"))
+	require.NoError(t, err)
+	require.Equal(t, "---\ndate: 2022-01-02\ntype: note\n---\n\n# Say \"hello\"\n", string(b))
+
+	// existing files are never overwritten
+	require.EqualError(t, createPost("2022/hello.md", data, false), `file "2022/hello.md" already exists`)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// metadataFields are front matter keys decoded into MarkdownFile fields,
+// all other keys are kept in MarkdownFile.Params
+var metadataFields = func() map[string]bool {
+	fields := map[string]bool{}
+
+	t := reflect.TypeOf(MarkdownFile{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+
+	return fields
+}()
+
+// parseParams returns front matter values that are not MarkdownFile fields,
+// nil if there are none
+func parseParams(values map[string]interface{}) map[string]interface{} {
+	var params map[string]interface{}
+
+	for key, value := range values {
+		if metadataFields[key] {
+			continue
+		}
+
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		params[key] = value
+	}
+
+	return params
+}
+
+// lookupParam returns value of the parameter by the dot-separated key,
+// e.g. "hero.color" for
+//
+//	hero:
+//	  color: "#ff0000"
+//
+// Items of lists are accessed by index, e.g. "links.0".
+func lookupParam(md *MarkdownFile, key string) (interface{}, bool) {
+	if md == nil {
+		return nil, false
+	}
+
+	var value interface{} = md.Params
+	for _, part := range strings.Split(key, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			item, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			value = item
+
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// param returns value of the parameter, nil if it's not set
+func param(md *MarkdownFile, key string) interface{} {
+	value, _ := lookupParam(md, key)
+	return value
+}
+
+// paramString returns the parameter as a string, empty string if it's not set
+func paramString(md *MarkdownFile, key string) string {
+	value, ok := lookupParam(md, key)
+	if !ok || value == nil {
+		return ""
+	}
+
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// paramBool returns the parameter as a bool, false if it's not set or not a bool
+func paramBool(md *MarkdownFile, key string) bool {
+	value, _ := lookupParam(md, key)
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// paramInt returns the parameter as an int, 0 if it's not set or not a number
+func paramInt(md *MarkdownFile, key string) int {
+	value, _ := lookupParam(md, key)
+
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
+
+// paramStrings returns the parameter as a list of strings,
+// a single value is returned as a list with one item
+func paramStrings(md *MarkdownFile, key string) []string {
+	value, ok := lookupParam(md, key)
+	if !ok || value == nil {
+		return nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return []string{paramString(md, key)}
+	}
+
+	result := make([]string, len(list))
+	for i, item := range list {
+		result[i] = fmt.Sprint(item)
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessParams(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		params  map[string]interface{}
+	}{
+		{
+			desc:    "No custom parameters",
+			content: "---\ndate: 2006-01-02\ntitle: Post\n---\n",
+			params:  nil,
+		},
+		{
+			desc:    "YAML",
+			content: "---\ndate: 2006-01-02\nhero_color: \"#ff0000\"\nhide_toc: true\nhero:\n  image: cover.jpg\n---\n",
+			params: map[string]interface{}{
+				"hero_color": "#ff0000",
+				"hide_toc":   true,
+				"hero":       map[string]interface{}{"image": "cover.jpg"},
+			},
+		},
+		{
+			desc:    "TOML",
+			content: "+++\ndate = 2006-01-02\nhide_toc = true\n[hero]\nimage = \"cover.jpg\"\n+++\n",
+			params: map[string]interface{}{
+				"hide_toc": true,
+				"hero":     map[string]interface{}{"image": "cover.jpg"},
+			},
+		},
+		{
+			desc:    "JSON",
+			content: "{\"date\": \"2006-01-02\", \"weight\": 3, \"links\": [\"a\", \"b\"]}\n",
+			params: map[string]interface{}{
+				"weight": 3,
+				"links":  []interface{}{"a", "b"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		cfg = config{}
+		md, err := processMarkdownFileContent("post.md", []byte(test.content))
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.params, md.Params, test.desc)
+	}
+}
+
+func TestParamFunctions(t *testing.T) {
+	md := &MarkdownFile{
+		Params: map[string]interface{}{
+			"hero_color":         "#ff0000",
+			"hide_toc":           true,
+			"weight":             3,
+			"canonical_override": nil,
+			"hero": map[string]interface{}{
+				"image": "cover.jpg",
+				"size":  2.5,
+				"wide":  "true",
+			},
+			"links": []interface{}{"a", "b"},
+		},
+	}
+
+	require.Equal(t, "#ff0000", param(md, "hero_color"))
+	require.Equal(t, nil, param(md, "missing"))
+	require.Equal(t, nil, param(nil, "hero_color"))
+
+	require.Equal(t, "#ff0000", paramString(md, "hero_color"))
+	require.Equal(t, "cover.jpg", paramString(md, "hero.image"))
+	require.Equal(t, "2.5", paramString(md, "hero.size"))
+	require.Equal(t, "b", paramString(md, "links.1"))
+	require.Equal(t, "", paramString(md, "links.2"))
+	require.Equal(t, "", paramString(md, "hero_color.image"))
+	require.Equal(t, "", paramString(md, "canonical_override"))
+
+	require.True(t, paramBool(md, "hide_toc"))
+	require.True(t, paramBool(md, "hero.wide"))
+	require.False(t, paramBool(md, "hero_color"))
+	require.False(t, paramBool(md, "missing"))
+
+	require.Equal(t, 3, paramInt(md, "weight"))
+	require.Equal(t, 2, paramInt(md, "hero.size"))
+	require.Equal(t, 0, paramInt(md, "hero_color"))
+
+	require.Equal(t, []string{"a", "b"}, paramStrings(md, "links"))
+	require.Equal(t, []string{"#ff0000"}, paramStrings(md, "hero_color"))
+	require.Nil(t, paramStrings(md, "missing"))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	permalinkToken = regexp.MustCompile(`:([a-z]+)`)
+	hrefToMD       = regexp.MustCompile(`href="([^"#?]+?)\.md(#[^"]*)?"`)
+)
+
+// permalinkTokens are placeholders that can be used in config.Permalinks patterns
+var permalinkTokens = map[string]func(md *MarkdownFile) (string, error){
+	"year":     dateToken("2006"),
+	"month":    dateToken("01"),
+	"day":      dateToken("02"),
+	"slug":     func(md *MarkdownFile) (string, error) { return md.slug(), nil },
+	"filename": func(md *MarkdownFile) (string, error) { return md.filename(), nil },
+	"section": func(md *MarkdownFile) (string, error) {
+		if !strings.Contains(md.Source, "/") {
+			return "", nil // post in the source root
+		}
+		return strings.SplitN(md.Source, "/", 2)[0], nil
+	},
+	"type": func(md *MarkdownFile) (string, error) { return md.ContentType, nil },
+}
+
+func dateToken(layout string) func(md *MarkdownFile) (string, error) {
+	return func(md *MarkdownFile) (string, error) {
+		if md.Date.IsZero() {
+			return "", errors.New("post has no date")
+		}
+		return md.Date.Format(layout), nil
+	}
+}
+
+// validatePermalinks checks that config.Permalinks patterns use only known tokens
+func (c config) validatePermalinks() error {
+	for contentType, pattern := range c.Permalinks {
+		for _, match := range permalinkToken.FindAllStringSubmatch(pattern, -1) {
+			if _, ok := permalinkTokens[match[1]]; !ok {
+				return errors.Errorf("unknown token %q in permalink pattern %q for type %q", match[0], pattern, contentType)
+			}
+		}
+	}
+	return nil
+}
+
+// permalink returns path of the HTML file for the post, relative to config.OutputDirectory.
+// Posts with config.Permalinks pattern for their ContentType follow it, e.g.
+// pattern "/:year/:month/:slug/" gives "2022/01/hello/index.html".
+// Other posts keep the path of the source file, with the slug as a file name if it's set.
+// With config.PrettyURLs posts and patterns without extension are rendered to "index.html"
+// in the directory named after the post, e.g. "2022/hello/index.html".
+// Posts in non-default language get "_<lang>" suffix, so that langToGetParameter works for them.
+func (c config) permalink(md *MarkdownFile) (string, error) {
+	if strings.ContainsAny(md.Slug, `/\`) || md.Slug == "." || md.Slug == ".." {
+		return "", errors.Errorf("invalid slug %q, it can't be a path", md.Slug)
+	}
+
+	pattern, ok := c.Permalinks[md.ContentType]
+	if !ok {
+		switch {
+		case c.PrettyURLs && md.slug() != "index":
+			pattern = path.Join(path.Dir(md.Source), ":slug") + "/"
+		case md.Slug == "":
+			return strings.Replace(md.Source, ".md", ".html", 1), nil
+		default:
+			pattern = path.Join(path.Dir(md.Source), ":slug")
+		}
+	}
+
+	var tokenErr error
+	p := permalinkToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		value, err := permalinkTokens[token[1:]](md)
+		if err != nil && tokenErr == nil {
+			tokenErr = errors.Wrapf(err, "permalink %q", pattern)
+		}
+		return value
+	})
+	if tokenErr != nil {
+		return "", tokenErr
+	}
+
+	// empty tokens leave empty path segments, e.g. "/:section/:slug/" -> "//hello/"
+	for strings.Contains(p, "//") {
+		p = strings.Replace(p, "//", "/", -1)
+	}
+
+	p = strings.TrimPrefix(p, "/")
+	switch {
+	case p == "" || strings.HasSuffix(p, "/"):
+		p += "index.html"
+	case path.Ext(p) == "" && c.PrettyURLs && path.Base(p) != "index":
+		p += "/index.html"
+	case path.Ext(p) == "":
+		p += ".html"
+	}
+
+	if md.Language != c.DefaultLanguage {
+		ext := path.Ext(p)
+		p = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(p, ext), md.Language, ext)
+	}
+
+	p = path.Clean(p)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", errors.Errorf("path %q is outside of the output directory", p)
+	}
+
+	return p, nil
+}
+
+// filename returns name of the source file without extension and language suffix
+func (md MarkdownFile) filename() string {
+	name := path.Base(md.ID)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// slug returns slug from metadata, file name by default
+func (md MarkdownFile) slug() string {
+	if md.Slug != "" {
+		return md.Slug
+	}
+	return md.filename()
+}
+
+// resolveLinks replaces links to markdown files in bodies and summaries of files
+// with links to the pages generated from them.
+// Links in bodies are relative to the page, links in summaries are absolute
+// and start with config.BasePath, because summaries are shown on other pages.
+// Links to unknown files are replaced with links to HTML files with the same name.
+func resolveLinks(files []*MarkdownFile) {
+	bySource := make(map[string]*MarkdownFile, len(files))
+	for _, file := range files {
+		bySource[file.Source] = file
+	}
+
+	for _, file := range files {
+		file.Body = file.resolveLinks(file.Body, bySource, false)
+		file.Summary = file.resolveLinks(file.Summary, bySource, true)
+	}
+}
+
+// resolveLinks replaces links to markdown files in html, see resolveLinks
+func (md MarkdownFile) resolveLinks(html string, bySource map[string]*MarkdownFile, absolute bool) string {
+	return hrefToMD.ReplaceAllStringFunc(html, func(match string) string {
+		submatch := hrefToMD.FindStringSubmatch(match)
+		href, fragment := submatch[1], submatch[2]
+
+		if isValidURL(href + ".md") {
+			return match // external link
+		}
+
+		if !absolute {
+			return `href="` + md.linkTo(href, bySource) + fragment + `"`
+		}
+
+		if !strings.HasPrefix(href, "/") {
+			href = "/" + path.Join(path.Dir(md.Source), href)
+		}
+
+		return `href="` + strings.TrimSuffix(cfg.BasePath, "/") + md.linkTo(href, bySource) + fragment + `"`
+	})
+}
+
+// linkTo returns link to the page generated from the markdown file href (without extension),
+// relative to the current page, or absolute if href is absolute
+func (md MarkdownFile) linkTo(href string, bySource map[string]*MarkdownFile) string {
+	source := path.Join(path.Dir(md.Source), href) + ".md"
+	if strings.HasPrefix(href, "/") {
+		source = strings.TrimPrefix(href, "/") + ".md"
+	}
+
+	target, ok := bySource[source]
+	if !ok {
+		return langToGetParameter(href + ".html")
+	}
+
+	if strings.HasPrefix(href, "/") {
+		return "/" + strings.TrimPrefix(langToGetParameter(target.Path), "./")
+	}
+
+	return langToGetParameter(relativePath(path.Dir(md.Path), target.Path))
+}
+
+// relativePath returns slash-separated path to target relative to dir
+func relativePath(dir, target string) string {
+	if dir == "." {
+		return target
+	}
+
+	from := strings.Split(dir, "/")
+	to := strings.Split(target, "/")
+
+	i := 0
+	for i < len(from) && i < len(to)-1 && from[i] == to[i] {
+		i++
+	}
+
+	return strings.Repeat("../", len(from)-i) + strings.Join(to[i:], "/")
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermalink(t *testing.T) {
+	postDate := date{Time: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		desc       string
+		permalinks map[string]string
+		pretty     bool
+		md         MarkdownFile
+		path       string
+		err        string
+	}{
+		{
+			desc: "Source path by default",
+			md:   MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en"},
+			path: "2022/hello.html",
+		},
+		{
+			desc: "Source path in non-default language",
+			md:   MarkdownFile{Source: "2022/hello_ru.md", ID: "2022/hello.md", ContentType: "post", Language: "ru"},
+			path: "2022/hello_ru.html",
+		},
+		{
+			desc: "Slug without pattern",
+			md:   MarkdownFile{Source: "2022/hello_ru.md", ID: "2022/hello.md", ContentType: "post", Language: "ru", Slug: "privet"},
+			path: "2022/privet_ru.html",
+		},
+		{
+			desc:       "Pattern with trailing slash",
+			permalinks: map[string]string{"post": "/:year/:month/:slug/"},
+			md:         MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en", Date: postDate},
+			path:       "2022/01/hello/index.html",
+		},
+		{
+			desc:       "Pattern with trailing slash in non-default language",
+			permalinks: map[string]string{"post": "/:year/:month/:slug/"},
+			md:         MarkdownFile{Source: "2022/hello_ru.md", ID: "2022/hello.md", ContentType: "post", Language: "ru", Date: postDate, Slug: "hi"},
+			path:       "2022/01/hi/index_ru.html",
+		},
+		{
+			desc:       "Pattern without extension",
+			permalinks: map[string]string{"page": "/:slug"},
+			md:         MarkdownFile{Source: "pages/about.md", ID: "pages/about.md", ContentType: "page", Language: "en"},
+			path:       "about.html",
+		},
+		{
+			desc:       "Pattern for another type",
+			permalinks: map[string]string{"page": "/:slug"},
+			md:         MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en"},
+			path:       "2022/hello.html",
+		},
+		{
+			desc:       "Section, type and day",
+			permalinks: map[string]string{"note": "/:section/:type/:day-:filename.htm"},
+			md:         MarkdownFile{Source: "blog/hello.md", ID: "blog/hello.md", ContentType: "note", Language: "en", Date: postDate},
+			path:       "blog/note/02-hello.htm",
+		},
+		{
+			desc:       "Section of a post in the source root",
+			permalinks: map[string]string{"post": "/:section/:slug/"},
+			md:         MarkdownFile{Source: "hello.md", ID: "hello.md", ContentType: "post", Language: "en"},
+			path:       "hello/index.html",
+		},
+		{
+			desc:   "Pretty URL",
+			pretty: true,
+			md:     MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en"},
+			path:   "2022/hello/index.html",
+		},
+		{
+			desc:   "Pretty URL in non-default language with slug",
+			pretty: true,
+			md:     MarkdownFile{Source: "2022/hello_ru.md", ID: "2022/hello.md", ContentType: "post", Language: "ru", Slug: "privet"},
+			path:   "2022/privet/index_ru.html",
+		},
+		{
+			desc:   "Pretty URL keeps index file",
+			pretty: true,
+			md:     MarkdownFile{Source: "blog/index.md", ID: "blog/index.md", ContentType: "page", Language: "en"},
+			path:   "blog/index.html",
+		},
+		{
+			desc:       "Pretty URL for pattern without extension",
+			permalinks: map[string]string{"page": "/:slug"},
+			pretty:     true,
+			md:         MarkdownFile{Source: "pages/about.md", ID: "pages/about.md", ContentType: "page", Language: "en"},
+			path:       "about/index.html",
+		},
+		{
+			desc:       "Pretty URL for pattern with extension",
+			permalinks: map[string]string{"page": "/:slug.htm"},
+			pretty:     true,
+			md:         MarkdownFile{Source: "pages/about.md", ID: "pages/about.md", ContentType: "page", Language: "en"},
+			path:       "about.htm",
+		},
+		{
+			desc:       "Pattern with date for post without date",
+			permalinks: map[string]string{"post": "/:year/:slug/"},
+			md:         MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en"},
+			err:        `permalink "/:year/:slug/": post has no date`,
+		},
+		{
+			desc: "Slug with path",
+			md:   MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en", Slug: "../../../evil"},
+			err:  `invalid slug "../../../evil", it can't be a path`,
+		},
+		{
+			desc:   "Parent directory slug",
+			pretty: true,
+			md:     MarkdownFile{Source: "hello.md", ID: "hello.md", ContentType: "post", Language: "en", Slug: ".."},
+			err:    `invalid slug "..", it can't be a path`,
+		},
+		{
+			desc:       "Pattern outside of output directory",
+			permalinks: map[string]string{"post": "/../:slug"},
+			md:         MarkdownFile{Source: "2022/hello.md", ID: "2022/hello.md", ContentType: "post", Language: "en"},
+			err:        `path "../hello.html" is outside of the output directory`,
+		},
+	}
+
+	for _, test := range tests {
+		c := config{DefaultLanguage: "en", Permalinks: test.permalinks, PrettyURLs: test.pretty}
+		path, err := c.permalink(&test.md)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.path, path, test.desc)
+	}
+}
+
+func TestValidatePermalinks(t *testing.T) {
+	c := config{Permalinks: map[string]string{"post": "/:year/:title/"}}
+	require.EqualError(t, c.validatePermalinks(), `unknown token ":title" in permalink pattern "/:year/:title/" for type "post"`)
+
+	c = config{Permalinks: map[string]string{"post": "/:year/:month/:day/:slug/"}}
+	require.NoError(t, c.validatePermalinks())
+}
+
+func TestResolveLinks(t *testing.T) {
+	cfg = config{DefaultLanguage: "en"}
+
+	files := []*MarkdownFile{
+		{
+			Source: "2022/a.md",
+			Path:   "2022/01/a/index.html",
+			Body: `<a href="b.md">B</a> <a href="b_ru.md#intro">B ru</a> <a href="/2021/c.md">C</a> ` +
+				`<a href="missing.md">Missing</a> <a href="https://example.com/README.md">README</a>`,
+			Summary: `<a href="b.md#intro">B</a> <a href="../2021/c.md">C</a> <a href="missing.md">Missing</a>`,
+		},
+		{Source: "2022/b.md", Path: "2022/b.html"},
+		{Source: "2022/b_ru.md", Path: "2022/b_ru.html"},
+		{Source: "2021/c.md", Path: "2021/c/index.html"},
+	}
+
+	resolveLinks(files)
+
+	require.Equal(
+		t,
+		`<a href="../../b.html">B</a> <a href="../../b.html?lang=ru#intro">B ru</a> <a href="/2021/c/index.html">C</a> `+
+			`<a href="missing.html">Missing</a> <a href="https://example.com/README.md">README</a>`,
+		files[0].Body,
+	)
+	require.Equal(t, `<a href="/2022/b.html#intro">B</a> <a href="/2021/c/index.html">C</a> <a href="/2022/missing.html">Missing</a>`, files[0].Summary)
+}
+
+func TestResolveLinksPrettyURLs(t *testing.T) {
+	cfg = config{DefaultLanguage: "en", PrettyURLs: true}
+	defer func() { cfg = config{} }()
+
+	files := []*MarkdownFile{
+		{
+			Source: "2022/a.md",
+			Path:   "2022/a/index.html",
+			Body:   `<a href="b_ru.md">B</a> <a href="/index.md">Home</a> <a href="missing.md">Missing</a>`,
+		},
+		{Source: "2022/b_ru.md", Path: "2022/b/index_ru.html"},
+		{Source: "index.md", Path: "index.html"},
+	}
+
+	resolveLinks(files)
+
+	require.Equal(t, `<a href="../b/?lang=ru">B</a> <a href="/">Home</a> <a href="missing.html">Missing</a>`, files[0].Body)
+}
+
+func TestResolveLinksInSummaryWithBasePath(t *testing.T) {
+	cfg = config{DefaultLanguage: "en", BasePath: "https://example.com/blog/"}
+	defer func() { cfg = config{} }()
+
+	files := []*MarkdownFile{
+		{
+			Source:  "2022/a.md",
+			Path:    "2022/a.html",
+			Body:    `<a href="b.md">B</a>`,
+			Summary: `<a href="b.md">B</a> <a href="/index.md#intro">Home</a>`,
+		},
+		{Source: "2022/b.md", Path: "2022/b/index.html"},
+		{Source: "index.md", Path: "index.html"},
+	}
+
+	resolveLinks(files)
+
+	require.Equal(t, `<a href="b/index.html">B</a>`, files[0].Body)
+	require.Equal(
+		t,
+		`<a href="https://example.com/blog/2022/b/index.html">B</a> <a href="https://example.com/blog/index.html#intro">Home</a>`,
+		files[0].Summary,
+	)
+}
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		dir    string
+		target string
+		path   string
+	}{
+		{dir: ".", target: "2022/post.html", path: "2022/post.html"},
+		{dir: "2022", target: "2022/post.html", path: "post.html"},
+		{dir: "2022", target: "2021/post.html", path: "../2021/post.html"},
+		{dir: "2022/01/a", target: "2022/01/b/index.html", path: "../b/index.html"},
+		{dir: "2022/post", target: "2022/post", path: "../post"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.path, relativePath(test.dir, test.target), test.dir+" -> "+test.target)
+	}
+}
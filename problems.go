@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kinds of problems, used to group them in the summary
+const (
+	problemStatic      = "static"
+	problemSearch      = "search"
+	problemMarkdown    = "markdown"
+	problemTranslation = "translation"
+	problemCopy        = "copy"
+	problemThumbnail   = "thumbnail"
+	problemTemplate    = "template"
+)
+
+// problem is a content error that is logged and skipped,
+// unless strict mode is enabled
+type problem struct {
+	Kind    string `json:"kind"`
+	Source  string `json:"source"` // path to the source file, if known
+	Message string `json:"message"`
+}
+
+func (p problem) String() string {
+	if p.Source == "" {
+		return p.Message
+	}
+	return p.Source + ": " + p.Message
+}
+
+// problemList collects problems found during the build, it's safe for concurrent use
+type problemList struct {
+	level string // log prefix, "ERROR" or "WARNING"
+	mu    sync.Mutex
+	list  []problem
+}
+
+// problems and warnings of the current build, they are reset in `run`
+var (
+	problems = &problemList{level: "ERROR"}
+	warnings = &problemList{level: "WARNING"}
+)
+
+// add logs and records the problem, the same problem is recorded only once
+func (pl *problemList) add(kind, source string, err error) {
+	p := problem{Kind: kind, Source: source, Message: err.Error()}
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	for _, existing := range pl.list {
+		if existing == p {
+			return
+		}
+	}
+
+	log.Printf("%s: %s %s", pl.level, kind, p)
+	pl.list = append(pl.list, p)
+}
+
+func (pl *problemList) len() int {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	return len(pl.list)
+}
+
+// sorted returns problems sorted by kind and source
+func (pl *problemList) sorted() []problem {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	result := append([]problem{}, pl.list...)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Source < result[j].Source
+	})
+	return result
+}
+
+// summary returns problems grouped by kind, e.g.
+//
+//	markdown (1):
+//	  2022/post.md: parsing metadata: ...
+func (pl *problemList) summary() string {
+	var (
+		b     strings.Builder
+		group []problem
+	)
+
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s (%d):\n", group[0].Kind, len(group))
+		for _, p := range group {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+		group = group[:0]
+	}
+
+	for _, p := range pl.sorted() {
+		if len(group) > 0 && group[0].Kind != p.Kind {
+			flush()
+		}
+		group = append(group, p)
+	}
+	flush()
+
+	return b.String()
+}
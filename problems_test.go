@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemListSummary(t *testing.T) {
+	pl := &problemList{level: "ERROR"}
+	pl.add(problemThumbnail, "2022/b.png", errors.New("read image"))
+	pl.add(problemMarkdown, "2022/b.md", errors.New("parsing metadata"))
+	pl.add(problemMarkdown, "2022/a.md", errors.New("parsing metadata"))
+	pl.add(problemTemplate, "", errors.New(`unknown sort field "date"`))
+	pl.add(problemTemplate, "", errors.New(`unknown sort field "date"`)) // duplicate
+
+	require.Equal(t, 4, pl.len())
+	require.Equal(
+		t,
+		"markdown (2):\n"+
+			"  2022/a.md: parsing metadata\n"+
+			"  2022/b.md: parsing metadata\n"+
+			"template (1):\n"+
+			"  unknown sort field \"date\"\n"+
+			"thumbnail (1):\n"+
+			"  2022/b.png: read image\n",
+		pl.summary(),
+	)
+}
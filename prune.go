@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pruneOutputDirectory removes files in cfg.OutputDirectory that were not written
+// by the current build, except the manifest, the search index and files matching
+// cfg.PruneKeep patterns. Directories left empty are removed too.
+// In dry-run mode it only logs files that would be removed.
+// It returns paths of stale files relative to cfg.OutputDirectory.
+func pruneOutputDirectory(m *manifest, dryRun bool) ([]string, error) {
+	var (
+		stale []string
+		dirs  []string
+	)
+
+	err := filepath.Walk(cfg.OutputDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		key := m.key(path)
+		if key == "." {
+			return nil
+		}
+
+		if keepFile(key) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+
+		if _, ok := m.current[key]; !ok {
+			stale = append(stale, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "walk output directory")
+	}
+
+	for _, key := range stale {
+		if dryRun {
+			log.Printf("Would remove %s", key)
+			continue
+		}
+
+		log.Printf("Removing %s", key)
+		if err := os.Remove(filepath.Join(cfg.OutputDirectory, key)); err != nil {
+			return nil, errors.Wrapf(err, "remove %q", key)
+		}
+	}
+
+	if dryRun {
+		return stale, nil
+	}
+
+	// remove nested directories first
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read directory %q", dir)
+		}
+
+		if len(entries) == 0 {
+			if err := os.Remove(dir); err != nil {
+				return nil, errors.Wrapf(err, "remove directory %q", dir)
+			}
+		}
+	}
+
+	return stale, nil
+}
+
+// keepFile reports whether the file (or directory) in cfg.OutputDirectory
+// should never be pruned: it's the manifest, the search index,
+// or it matches one of cfg.PruneKeep patterns, or it is inside a matching directory.
+func keepFile(key string) bool {
+	if key == manifestFile {
+		return true
+	}
+
+	if rel, err := filepath.Rel(cfg.OutputDirectory, cfg.SearchPath); err == nil &&
+		filepath.ToSlash(rel) == key {
+		return true
+	}
+
+	for _, pattern := range cfg.PruneKeep {
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		if ok, _ := filepath.Match(pattern, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
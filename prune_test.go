@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneOutputDirectory(t *testing.T) {
+	cfg = config{
+		OutputDirectory: t.TempDir(),
+		PruneKeep:       []string{"CNAME", "/.well-known/"},
+	}
+
+	files := []string{
+		"index.html",
+		"2022/post.html",
+		"2022/old-post.html",
+		"thumb/2022/old.png",
+		"CNAME",
+		".well-known/security.txt",
+		manifestFile,
+	}
+	for _, file := range files {
+		path := filepath.Join(cfg.OutputDirectory, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), permDir))
+		require.NoError(t, ioutil.WriteFile(path, []byte(file), permFile))
+	}
+
+	m := &manifest{current: map[string]string{
+		"index.html":     "",
+		"2022/post.html": "",
+	}}
+
+	// dry run doesn't remove anything
+	stale, err := pruneOutputDirectory(m, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2022/old-post.html", "thumb/2022/old.png"}, stale)
+	require.FileExists(t, filepath.Join(cfg.OutputDirectory, "2022/old-post.html"))
+
+	stale, err = pruneOutputDirectory(m, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2022/old-post.html", "thumb/2022/old.png"}, stale)
+
+	require.NoFileExists(t, filepath.Join(cfg.OutputDirectory, "2022/old-post.html"))
+	require.NoDirExists(t, filepath.Join(cfg.OutputDirectory, "thumb"))
+	require.FileExists(t, filepath.Join(cfg.OutputDirectory, "2022/post.html"))
+	require.FileExists(t, filepath.Join(cfg.OutputDirectory, "CNAME"))
+	require.FileExists(t, filepath.Join(cfg.OutputDirectory, ".well-known/security.txt"))
+	require.FileExists(t, filepath.Join(cfg.OutputDirectory, manifestFile))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// countWords returns number of words in the HTML.
+// Chinese and Japanese texts have no spaces between words,
+// so every Han, Hiragana and Katakana character is counted as a word.
+func countWords(body string) int {
+	text := html.UnescapeString(htmlTag.ReplaceAllString(body, " "))
+
+	count := 0
+	for _, field := range strings.Fields(text) {
+		inWord := false
+		for _, r := range field {
+			switch {
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+				count++
+				inWord = false
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				if !inWord {
+					count++
+					inWord = true
+				}
+			}
+		}
+	}
+
+	return count
+}
+
+// readingTime returns reading time in minutes, rounded up
+func readingTime(words, wordsPerMinute int) int {
+	if words == 0 || wordsPerMinute <= 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// wordsPerMinute returns reading speed for the language
+func (c config) wordsPerMinute(lang string) int {
+	if l := c.language(lang); l.WordsPerMinute > 0 {
+		return l.WordsPerMinute
+	}
+	return c.WordsPerMinute
+}
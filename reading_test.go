@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		body  string
+		count int
+	}{
+		{body: "", count: 0},
+		{body: "<p>Hello, world!</p>", count: 2},
+		{body: "<p>One</p><p>two &mdash; three</p>", count: 3},
+		{body: "<p>Don't re-use <code>go vet</code></p>", count: 4},
+		{body: "<p>Привет, мир</p>", count: 2},
+		{body: "<p>你好世界</p>", count: 4},
+		{body: "<p>こんにちは Go</p>", count: 6},
+		{body: "<p>안녕하세요 세계</p>", count: 2},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.count, countWords(test.body), test.body)
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	require.Equal(t, 0, readingTime(0, 200))
+	require.Equal(t, 1, readingTime(1, 200))
+	require.Equal(t, 1, readingTime(200, 200))
+	require.Equal(t, 2, readingTime(201, 200))
+	require.Equal(t, 0, readingTime(100, 0))
+}
+
+func TestProcessReadingTime(t *testing.T) {
+	cfg = config{
+		DefaultLanguage: "en",
+		WordsPerMinute:  2,
+		Languages: map[string]languageConfig{
+			"ru": {WordsPerMinute: 1},
+		},
+	}
+
+	md, err := processMarkdownFileContent("post.md", []byte("# Title\nOne two three"))
+	require.NoError(t, err)
+	require.Equal(t, 3, md.WordCount)
+	require.Equal(t, 2, md.ReadingTime)
+
+	md, err = processMarkdownFileContent("post_ru.md", []byte("# Заголовок\nРаз два три"))
+	require.NoError(t, err)
+	require.Equal(t, 3, md.WordCount)
+	require.Equal(t, 3, md.ReadingTime)
+}
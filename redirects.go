@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// redirectFormats are server redirect files that can be written with config.Redirects,
+// by format name
+var redirectFormats = map[string]struct {
+	filename string // relative to config.OutputDirectory
+	line     string // format of one redirect, with "from" and "to" arguments
+}{
+	"netlify": {"_redirects", "%s %s 301\n"},
+	"nginx":   {"redirects.map", "%s %s;\n"},
+	"caddy":   {"redirects.caddy", "redir %s %s permanent\n"},
+}
+
+// aliasTemplate is a page written for every alias,
+// it redirects browsers and tells search engines where the post is now
+var aliasTemplate = template.Must(template.New("alias").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ html .To }}</title>
+<link rel="canonical" href="{{ html .To }}">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url={{ html .To }}">
+</head>
+</html>
+`))
+
+// redirect from an old URL path of the post to its current URL
+type redirect struct {
+	From   string // URL path, starting with "/"
+	To     string // URL of the post
+	Path   string // path of the alias page, relative to config.OutputDirectory
+	Source string // source markdown file of the post
+}
+
+// validateRedirects checks that config.Redirects has only known formats
+func (c config) validateRedirects() error {
+	for _, format := range c.Redirects {
+		if _, ok := redirectFormats[format]; !ok {
+			return errors.Errorf("unknown redirects format %q, use netlify, nginx or caddy", format)
+		}
+	}
+	return nil
+}
+
+// aliases returns redirects for `aliases` of all files, sorted by From.
+// Aliases outside of the output directory, or that point to existing pages,
+// files already written by the build or to other aliases are reported as problems.
+func aliases(files []*MarkdownFile) []redirect {
+	used := make(map[string]string, len(files)) // output path -> source
+	for _, file := range files {
+		used[file.Path] = file.Source
+	}
+
+	var result []redirect
+	for _, file := range files {
+		for _, alias := range file.Aliases {
+			r := redirect{
+				From:   "/" + strings.TrimPrefix(alias, "/"),
+				To:     postURL(file),
+				Path:   aliasPath(alias),
+				Source: file.Source,
+			}
+
+			if r.Path == ".." || strings.HasPrefix(r.Path, "../") {
+				problems.add(problemMarkdown, file.Source, errors.Errorf("alias %q is outside of the output directory", alias))
+				continue
+			}
+
+			if source, ok := used[r.Path]; ok {
+				problems.add(problemMarkdown, file.Source, errors.Errorf("alias %q is already used by %q", alias, source))
+				continue
+			}
+
+			// template pages, static and copied files
+			if outputs.recorded(r.Path) {
+				problems.add(problemMarkdown, file.Source, errors.Errorf("alias %q overwrites %q", alias, r.Path))
+				continue
+			}
+			used[r.Path] = file.Source
+
+			result = append(result, r)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].From < result[j].From })
+	return result
+}
+
+// aliasPath returns path of the alias page,
+// aliases without extension are directories: "/2021/post/" -> "2021/post/index.html"
+func aliasPath(alias string) string {
+	p := strings.TrimPrefix(alias, "/")
+	if p == "" || strings.HasSuffix(p, "/") || path.Ext(p) == "" {
+		p = path.Join(p, "index.html")
+	}
+	return path.Clean(p)
+}
+
+// postURL returns URL of the post with config.BasePath
+func postURL(md *MarkdownFile) string {
+	return strings.TrimSuffix(cfg.BasePath, "/") + "/" + strings.TrimPrefix(md.Canonical, "./")
+}
+
+// writeRedirects writes alias pages and server redirect files of config.Redirects formats
+func writeRedirects(redirects []redirect) error {
+	for _, r := range redirects {
+		var buf bytes.Buffer
+		if err := aliasTemplate.Execute(&buf, r); err != nil {
+			return errors.Wrapf(err, "render alias %q", r.From)
+		}
+
+		written, err := writeOutput(filepath.Join(cfg.OutputDirectory, r.Path), buf.Bytes())
+		if err != nil {
+			return errors.Wrapf(err, "write alias %q", r.From)
+		}
+
+		count(written, &report.AliasesWritten, &report.AliasesUpToDate)
+	}
+
+	for _, name := range cfg.Redirects {
+		format := redirectFormats[name]
+
+		var buf bytes.Buffer
+		for _, r := range redirects {
+			fmt.Fprintf(&buf, format.line, r.From, r.To)
+		}
+
+		if _, err := writeOutput(filepath.Join(cfg.OutputDirectory, format.filename), buf.Bytes()); err != nil {
+			return errors.Wrapf(err, "write %s redirects", name)
+		}
+	}
+
+	return nil
+}
+
+// writeOutput writes content to the file unless it's up to date.
+// It returns true if the file was written.
+func writeOutput(filename string, content []byte) (bool, error) {
+	return outputs.build(
+		filename,
+		func() (string, error) { return hashStrings(string(content)), nil },
+		func() error {
+			if err := createDirectory(filepath.Dir(filename)); err != nil {
+				return err
+			}
+			return ioutil.WriteFile(filename, content, permFile)
+		},
+	)
+}
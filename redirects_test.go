@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliases(t *testing.T) {
+	cfg = config{BasePath: "https://example.com/"}
+	problems = &problemList{level: "ERROR"}
+	outputs = &manifest{current: map[string]string{"2022/new.html": "", "index.html": "", "css/index.html": ""}}
+
+	files := []*MarkdownFile{
+		{
+			Source:    "2022/new.md",
+			Path:      "2022/new.html",
+			Canonical: "2022/new.html",
+			Aliases:   []string{"/2021/old.html", "2021/older/", "/2022/new.html"},
+		},
+		{
+			Source:    "2022/new_ru.md",
+			Path:      "2022/new_ru.html",
+			Canonical: "2022/new.html?lang=ru",
+			Aliases:   []string{"/2021/old_ru.html", "/2021/old.html"},
+		},
+		{
+			Source:    "2022/other.md",
+			Path:      "2022/other.html",
+			Canonical: "2022/other.html",
+			Aliases:   []string{"/", "/css/", "../../evil/", "/2021/../../evil.html"},
+		},
+	}
+
+	require.Equal(
+		t,
+		[]redirect{
+			{From: "/2021/old.html", To: "https://example.com/2022/new.html", Path: "2021/old.html", Source: "2022/new.md"},
+			{From: "/2021/old_ru.html", To: "https://example.com/2022/new.html?lang=ru", Path: "2021/old_ru.html", Source: "2022/new_ru.md"},
+			{From: "/2021/older/", To: "https://example.com/2022/new.html", Path: "2021/older/index.html", Source: "2022/new.md"},
+		},
+		aliases(files),
+	)
+
+	require.Equal(
+		t,
+		[]problem{
+			{Kind: problemMarkdown, Source: "2022/new.md", Message: `alias "/2022/new.html" is already used by "2022/new.md"`},
+			{Kind: problemMarkdown, Source: "2022/new_ru.md", Message: `alias "/2021/old.html" is already used by "2022/new.md"`},
+			{Kind: problemMarkdown, Source: "2022/other.md", Message: `alias "/" overwrites "index.html"`},
+			{Kind: problemMarkdown, Source: "2022/other.md", Message: `alias "/css/" overwrites "css/index.html"`},
+			{Kind: problemMarkdown, Source: "2022/other.md", Message: `alias "../../evil/" is outside of the output directory`},
+			{Kind: problemMarkdown, Source: "2022/other.md", Message: `alias "/2021/../../evil.html" is outside of the output directory`},
+		},
+		problems.sorted(),
+	)
+}
+
+func TestWriteRedirects(t *testing.T) {
+	cfg = config{OutputDirectory: t.TempDir(), Redirects: []string{"netlify", "nginx", "caddy"}}
+	outputs = &manifest{current: map[string]string{}}
+	report = newBuildReport()
+
+	redirects := []redirect{
+		{From: "/2021/old.html", To: "/2022/new.html", Path: "2021/old.html"},
+		{From: "/2021/older/", To: "/2022/new.html?lang=ru", Path: "2021/older/index.html"},
+	}
+	require.NoError(t, writeRedirects(redirects))
+
+	b, err := ioutil.ReadFile(filepath.Join(cfg.OutputDirectory, "2021/older/index.html"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), `<link rel="canonical" href="/2022/new.html?lang=ru">`)
+	require.Contains(t, string(b), `<meta http-equiv="refresh" content="0; url=/2022/new.html?lang=ru">`)
+
+	files := map[string]string{
+		"_redirects":      "/2021/old.html /2022/new.html 301\n/2021/older/ /2022/new.html?lang=ru 301\n",
+		"redirects.map":   "/2021/old.html /2022/new.html;\n/2021/older/ /2022/new.html?lang=ru;\n",
+		"redirects.caddy": "redir /2021/old.html /2022/new.html permanent\nredir /2021/older/ /2022/new.html?lang=ru permanent\n",
+	}
+	for name, content := range files {
+		b, err := ioutil.ReadFile(filepath.Join(cfg.OutputDirectory, name))
+		require.NoError(t, err, name)
+		require.Equal(t, content, string(b), name)
+	}
+
+	require.Len(t, outputs.current, 5)
+	require.Equal(t, int64(2), report.AliasesWritten)
+	require.Zero(t, report.PagesRendered)
+}
+
+func TestValidateRedirects(t *testing.T) {
+	require.NoError(t, config{Redirects: []string{"netlify"}}.validateRedirects())
+	require.EqualError(
+		t,
+		config{Redirects: []string{"apache"}}.validateRedirects(),
+		`unknown redirects format "apache", use netlify, nginx or caddy`,
+	)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// buildReport is a machine-readable summary of the build,
+// it's written to cfg.Report file as JSON
+type buildReport struct {
+	// counters are updated from multiple goroutines with sync/atomic
+	PagesRendered      int64           `json:"pages_rendered"`
+	PagesUpToDate      int64           `json:"pages_up_to_date"`
+	FilesCopied        int64           `json:"files_copied"`
+	FilesUpToDate      int64           `json:"files_up_to_date"`
+	ThumbnailsCreated  int64           `json:"thumbnails_created"`
+	ThumbnailsUpToDate int64           `json:"thumbnails_up_to_date"`
+	AliasesWritten     int64           `json:"aliases_written"`
+	AliasesUpToDate    int64           `json:"aliases_up_to_date"`
+	DraftsSkipped      int64           `json:"drafts_skipped"`
+	ScheduledSkipped   int64           `json:"scheduled_skipped"`
+	ExpiredSkipped     int64           `json:"expired_skipped"`
+	StartedAt          time.Time       `json:"started_at"`
+	DurationMs         int64           `json:"duration_ms"`
+	Stages             []stageTiming   `json:"stages"`
+	Warnings           []problem       `json:"warnings"`
+	Errors             []problem       `json:"errors"`
+	Tags               TagsCounterList `json:"tags"`
+	Error              string          `json:"error,omitempty"` // error that stopped the build
+
+	mu sync.Mutex // guards Stages
+}
+
+// stageTiming is the time spent in one stage of the build
+type stageTiming struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// report of the current build, it's reset in `run`
+var report = &buildReport{}
+
+func newBuildReport() *buildReport {
+	return &buildReport{
+		StartedAt: time.Now(),
+		Stages:    []stageTiming{},
+		Warnings:  []problem{},
+		Errors:    []problem{},
+		Tags:      TagsCounterList{},
+	}
+}
+
+// stage records time spent in the stage, since start
+func (r *buildReport) stage(name string, start time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Stages = append(r.Stages, stageTiming{
+		Name:       name,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+}
+
+// count increments written or upToDate counter
+func count(written bool, writtenCounter, upToDateCounter *int64) {
+	if written {
+		atomic.AddInt64(writtenCounter, 1)
+	} else {
+		atomic.AddInt64(upToDateCounter, 1)
+	}
+}
+
+// write saves the report as JSON file,
+// buildErr is the error returned by the build, if any
+func (r *buildReport) write(path string, buildErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.DurationMs = time.Since(r.StartedAt).Milliseconds()
+	r.Warnings = warnings.sorted()
+	r.Errors = problems.sorted()
+	if buildErr != nil {
+		r.Error = buildErr.Error()
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal report")
+	}
+
+	if err := ioutil.WriteFile(path, b, permFile); err != nil {
+		return errors.Wrapf(err, "write report %q", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReportWrite(t *testing.T) {
+	problems = &problemList{level: "ERROR"}
+	warnings = &problemList{level: "WARNING"}
+	problems.add(problemMarkdown, "2022/post.md", errors.New("parsing metadata"))
+
+	r := newBuildReport()
+	r.stage("scan", time.Now())
+	count(true, &r.PagesRendered, &r.PagesUpToDate)
+	count(false, &r.PagesRendered, &r.PagesUpToDate)
+	count(false, &r.PagesRendered, &r.PagesUpToDate)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, r.write(path, errors.New("rendering pages")))
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &result))
+
+	require.Equal(t, float64(1), result["pages_rendered"])
+	require.Equal(t, float64(2), result["pages_up_to_date"])
+	require.Equal(t, "rendering pages", result["error"])
+	require.Equal(t, []interface{}{}, result["warnings"])
+	require.Equal(
+		t,
+		[]interface{}{map[string]interface{}{
+			"kind":    "markdown",
+			"source":  "2022/post.md",
+			"message": "parsing metadata",
+		}},
+		result["errors"],
+	)
+	require.Len(t, result["stages"], 1)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadPath is the endpoint the injected script listens to for rebuild events
+const reloadPath = "/_genblog/reload"
+
+const reloadScript = `<script>new EventSource("` + reloadPath + `").onmessage = function() { location.reload(); };</script>`
+
+// watchInterval is how often watched directories are scanned for changes
+const watchInterval = 500 * time.Millisecond
+
+// reloader notifies connected browser tabs when the site is rebuilt,
+// using Server-Sent Events.
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newReloader() *reloader {
+	return &reloader{clients: make(map[chan struct{}]struct{})}
+}
+
+func (rl *reloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	ch := make(chan struct{}, 1)
+
+	rl.mu.Lock()
+	rl.clients[ch] = struct{}{}
+	rl.mu.Unlock()
+
+	defer func() {
+		rl.mu.Lock()
+		delete(rl.clients, ch)
+		rl.mu.Unlock()
+	}()
+
+	select {
+	case <-ch:
+		fmt.Fprint(w, "data: reload\n\n")
+		flusher.Flush()
+	case <-r.Context().Done():
+	}
+}
+
+// reload sends reload event to all connected clients
+func (rl *reloader) reload() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for ch := range rl.clients {
+		select {
+		case ch <- struct{}{}:
+		default: // client already has a pending event
+		}
+	}
+}
+
+// withReloadScript serves HTML pages from dir with reloadScript injected,
+// all other requests are passed to next handler.
+// It also resolves `?lang=xx` GET parameter to the `_xx.html` file,
+// the same way reverse proxy does it in production (see langToGetParameter).
+func withReloadScript(dir string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		if strings.HasSuffix(r.URL.Path, "/") {
+			name = filepath.Join(name, "index.html")
+		}
+
+		if filepath.Ext(name) != ".html" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if lang := r.URL.Query().Get("lang"); lang != "" && lang != cfg.DefaultLanguage {
+			localized := strings.TrimSuffix(name, ".html") + "_" + lang + ".html"
+			if _, err := os.Stat(localized); err == nil {
+				name = localized
+			}
+		}
+
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(injectReloadScript(b))
+	})
+}
+
+// injectReloadScript adds reloadScript before closing body tag,
+// or to the end of the page if there is no body tag
+func injectReloadScript(page []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i == -1 {
+		return append(page, reloadScript...)
+	}
+
+	result := make([]byte, 0, len(page)+len(reloadScript))
+	result = append(result, page[:i]...)
+	result = append(result, reloadScript...)
+	return append(result, page[i:]...)
+}
+
+// watch scans dirs every interval and calls onChange
+// when any file is added, removed or modified
+func watch(dirs []string, interval time.Duration, onChange func()) {
+	prev := snapshot(dirs)
+
+	for range time.Tick(interval) {
+		if current := snapshot(dirs); current != prev {
+			prev = current
+			onChange()
+		}
+	}
+}
+
+// snapshot returns a hash of paths, sizes and modification times
+// of all files in dirs, skipping .git and outputs of the build (see watchSkipped)
+func snapshot(dirs []string) uint64 {
+	h := fnv.New64a()
+	skipped := watchSkipped()
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // file might be removed while walking, it will be picked up next time
+			}
+
+			if path != dir && (info.Name() == ".git" || isWithin(path, skipped)) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// directories change when the build writes into them, their files are enough
+			if info.IsDir() {
+				return nil
+			}
+
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+
+	return h.Sum64()
+}
+
+// watchSkipped returns absolute paths written by the build, changes in them don't trigger rebuild
+func watchSkipped() []string {
+	var skipped []string
+
+	for _, path := range []string{
+		cfg.OutputDirectory,
+		filepath.Join(cfg.OutputDirectory, manifestFile),
+		cfg.SearchPath,
+		cfg.Report,
+	} {
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			skipped = append(skipped, abs)
+		}
+	}
+
+	return skipped
+}
+
+// isWithin reports whether the path is one of dirs or inside one of them
+func isWithin(path string, dirs []string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	for _, dir := range dirs {
+		if abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// serve builds the site and serves cfg.OutputDirectory on addr.
+// If watchChanges is true, it rebuilds the site on changes in source, templates
+// and static directories, and reloads open browser tabs.
+func serve(addr string, watchChanges bool) error {
+	if err := run(); err != nil {
+		// keep serving, so that the problem can be fixed without restart
+		log.Printf("ERROR: %v", err)
+	}
+
+	var handler http.Handler = http.FileServer(http.Dir(cfg.OutputDirectory))
+
+	if watchChanges {
+		rl := newReloader()
+
+		mux := http.NewServeMux()
+		mux.Handle(reloadPath, rl)
+		mux.Handle("/", withReloadScript(cfg.OutputDirectory, handler))
+		handler = mux
+
+		dirs := []string{cfg.SourceDirectory, cfg.TemplatesDirectory, cfg.StaticDirectory}
+		go watch(dirs, watchInterval, func() {
+			log.Println("Changes detected, rebuilding...")
+			t := time.Now()
+
+			if err := run(); err != nil {
+				log.Printf("ERROR: %v", err)
+				return
+			}
+
+			log.Printf("Rebuilt in %dms", time.Since(t).Milliseconds())
+			rl.reload()
+		})
+	}
+
+	log.Printf("Serving %s on http://%s", cfg.OutputDirectory, addr)
+	return http.ListenAndServe(addr, handler)
+}
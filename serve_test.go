@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectReloadScript(t *testing.T) {
+	tests := []struct {
+		page   string
+		result string
+	}{
+		{
+			page:   "<html><body>Post</body></html>",
+			result: "<html><body>Post" + reloadScript + "</body></html>",
+		},
+		{
+			page:   "<HTML><BODY>Post</BODY></HTML>",
+			result: "<HTML><BODY>Post" + reloadScript + "</BODY></HTML>",
+		},
+		{
+			page:   "Post",
+			result: "Post" + reloadScript,
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.result, string(injectReloadScript([]byte(test.page))))
+	}
+}
+
+func TestSnapshotSkipsBuildOutputs(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	dir := t.TempDir()
+	cfg = config{
+		OutputDirectory: filepath.Join(dir, "output"),
+		SearchPath:      filepath.Join(dir, "search_index"),
+		Report:          filepath.Join(dir, "report.json"),
+	}
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "post.md"), []byte("# Post"), permFile))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "outputs.md"), []byte("# Outputs"), permFile))
+	before := snapshot([]string{dir})
+
+	for _, path := range []string{"output/index.html", "search_index/store/root.bolt", "report.json"} {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), permDir))
+		require.NoError(t, ioutil.WriteFile(path, []byte("x"), permFile))
+	}
+	require.Equal(t, before, snapshot([]string{dir}), "build outputs are skipped")
+
+	// "outputs.md" starts with "output", but it's a source
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "outputs.md"), []byte("# Changed outputs"), permFile))
+	require.NotEqual(t, before, snapshot([]string{dir}))
+}
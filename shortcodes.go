@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/pkg/errors"
+)
+
+// shortcodes are templates in config.TemplatesDirectory/shortcodes,
+// one file per shortcode, e.g. figure.html for {{< figure >}}.
+// nil if there are no shortcode templates.
+var shortcodes *template.Template
+
+var (
+	// shortcodeTag is an opening, closing or self-closing tag:
+	// {{< name args >}}, {{< /name >}} or {{< name args />}}
+	shortcodeTag = regexp.MustCompile(`(?s)\{\{<\s*(/?)\s*([\w-]+)(.*?)(/?)>\}\}`)
+	// shortcodeEscaped is a tag in comments, kept as is: {{</* name */>}} -> {{< name >}}
+	shortcodeEscaped = regexp.MustCompile(`(?s)\{\{</\*(.*?)\*/>\}\}`)
+	// shortcodeArg is a named argument or a positional one, both may be quoted
+	shortcodeArg = regexp.MustCompile(`([\w-]+)=("(?:[^"\\]|\\.)*"|\S+)|("(?:[^"\\]|\\.)*"|\S+)`)
+)
+
+// shortcode is data passed to the shortcode template
+type shortcode struct {
+	Name   string
+	Params map[string]string // named arguments, e.g. src="x.jpg"
+	Args   []string          // positional arguments, e.g. {{< youtube id >}}
+	Inner  string            // content between opening and closing tags rendered to HTML
+	Page   *MarkdownFile     // post with the shortcode
+}
+
+// Get returns positional argument by index or named argument by name,
+// empty string if it's not set: {{ .Get 0 }}, {{ .Get "src" }}
+func (s shortcode) Get(key interface{}) string {
+	switch k := key.(type) {
+	case int:
+		if k >= 0 && k < len(s.Args) {
+			return s.Args[k]
+		}
+	case string:
+		return s.Params[k]
+	}
+	return ""
+}
+
+// loadShortcodes parses templates in config.TemplatesDirectory/shortcodes,
+// returns nil if there are none
+func loadShortcodes() (*template.Template, error) {
+	files, err := filepath.Glob(filepath.Join(cfg.TemplatesDirectory, "shortcodes", "*.html"))
+	if err != nil {
+		return nil, errors.Wrap(err, "shortcodes listing")
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	t, err := template.New("").Funcs(fm).ParseFiles(files...)
+	if err != nil {
+		return nil, errors.Wrap(err, "shortcodes parsing")
+	}
+
+	return t, nil
+}
+
+// expandShortcodes replaces shortcodes in the markdown body with rendered templates.
+// Paired shortcodes get the content between tags rendered from markdown in .Inner,
+// shortcodes in it are expanded first.
+func (md *MarkdownFile) expandShortcodes(b []byte) ([]byte, error) {
+	if !bytes.Contains(b, []byte("{{<")) {
+		return b, nil
+	}
+
+	opts, err := cfg.markdownOptions(md)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := md.expand(string(b), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(result), nil
+}
+
+func (md *MarkdownFile) expand(s string, opts markdownOptions) (string, error) {
+	var buf strings.Builder
+
+	for {
+		loc := shortcodeTag.FindStringSubmatchIndex(s)
+		if esc := shortcodeEscaped.FindStringSubmatchIndex(s); esc != nil && (loc == nil || esc[0] < loc[0]) {
+			buf.WriteString(s[:esc[0]] + "{{<" + s[esc[2]:esc[3]] + ">}}")
+			s = s[esc[1]:]
+			continue
+		}
+
+		if loc == nil {
+			buf.WriteString(s)
+			return buf.String(), nil
+		}
+
+		closing := s[loc[2]:loc[3]] == "/"
+		name := s[loc[4]:loc[5]]
+		args := s[loc[6]:loc[7]]
+		selfClosing := s[loc[8]:loc[9]] == "/"
+
+		if closing {
+			return "", errors.Errorf("unexpected closing shortcode %q", name)
+		}
+
+		buf.WriteString(s[:loc[0]])
+		s = s[loc[1]:]
+
+		data := shortcode{Name: name, Page: md}
+		data.Params, data.Args = parseShortcodeArgs(args)
+
+		if !selfClosing {
+			if start, end := findClosingShortcode(s, name); start >= 0 {
+				inner, err := md.expand(s[:start], opts)
+				if err != nil {
+					return "", errors.Wrapf(err, "shortcode %q", name)
+				}
+
+				b := []byte(strings.TrimSpace(inner) + "\n")
+				if err := checkInnerMarkdown(b, opts); err != nil {
+					return "", errors.Wrapf(err, "shortcode %q", name)
+				}
+
+				html, _ := renderMarkdown(b, opts)
+				data.Inner = string(html)
+				s = s[end:]
+			}
+		}
+
+		if err := executeShortcode(&buf, data); err != nil {
+			return "", err
+		}
+	}
+}
+
+// checkInnerMarkdown returns an error if the content of a paired shortcode has headings or footnotes.
+// It's rendered separately from the post, so its headings would be missing
+// from the table of contents and could get the same IDs as the post ones,
+// and its footnotes would be numbered from 1 again.
+func checkInnerMarkdown(b []byte, opts markdownOptions) error {
+	var err error
+
+	doc := markdown.Parse(normalizeFences(b), parser.NewWithExtensions(opts.extensions))
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *ast.Heading:
+			if !node.IsTitleblock {
+				err = errors.New("headings are not supported in the content between tags")
+				return ast.Terminate
+			}
+		case *ast.Link:
+			if node.NoteID != 0 {
+				err = errors.New("footnotes are not supported in the content between tags")
+				return ast.Terminate
+			}
+		}
+		return ast.GoToNext
+	})
+
+	return err
+}
+
+// findClosingShortcode returns position of the closing tag of the shortcode,
+// skipping nested shortcodes with the same name, -1 if there is none
+func findClosingShortcode(s, name string) (int, int) {
+	depth := 0
+
+	for _, loc := range shortcodeTag.FindAllStringSubmatchIndex(s, -1) {
+		if s[loc[4]:loc[5]] != name || s[loc[8]:loc[9]] == "/" {
+			continue
+		}
+
+		if s[loc[2]:loc[3]] != "/" {
+			depth++
+			continue
+		}
+
+		if depth == 0 {
+			return loc[0], loc[1]
+		}
+		depth--
+	}
+
+	return -1, -1
+}
+
+// parseShortcodeArgs returns named and positional arguments,
+// e.g. `src="x.jpg" caption="A \"cat\""` or `abc123 autoplay`
+func parseShortcodeArgs(s string) (map[string]string, []string) {
+	var (
+		params map[string]string
+		args   []string
+	)
+
+	for _, m := range shortcodeArg.FindAllStringSubmatch(s, -1) {
+		if m[1] == "" {
+			args = append(args, unquoteArg(m[3]))
+			continue
+		}
+
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[m[1]] = unquoteArg(m[2])
+	}
+
+	return params, args
+}
+
+// unquoteArg returns the string without quotes, or as is if it's not quoted
+func unquoteArg(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil && strings.HasPrefix(s, `"`) {
+		return unquoted
+	}
+	return s
+}
+
+func executeShortcode(buf *strings.Builder, data shortcode) error {
+	var t *template.Template
+	if shortcodes != nil {
+		t = shortcodes.Lookup(data.Name + ".html")
+	}
+
+	if t == nil {
+		return errors.Errorf("unknown shortcode %q", data.Name)
+	}
+
+	if err := t.Execute(buf, data); err != nil {
+		return errors.Wrapf(err, "shortcode %q", data.Name)
+	}
+
+	return nil
+}
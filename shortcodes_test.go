@@ -0,0 +1,129 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandShortcodes(t *testing.T) {
+	defer func() { cfg, shortcodes = config{}, nil }()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "shortcodes"), permDir))
+	for name, content := range map[string]string{
+		"figure.html":  `<figure><img src="{{ .Get "src" }}" alt="{{ .Get "alt" }}"><figcaption>{{ .Get "caption" }}</figcaption></figure>`,
+		"youtube.html": `<iframe src="https://www.youtube.com/embed/{{ .Get 0 }}"></iframe>`,
+		"note.html":    `<aside class="note {{ .Get "type" }}">{{ .Inner }}</aside>`,
+		"lang.html":    `{{ .Page.Language }}`,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shortcodes", name), []byte(content), permFile))
+	}
+
+	cfg = config{TemplatesDirectory: dir, MarkdownExtensions: []string{"footnotes"}}
+
+	var err error
+	shortcodes, err = loadShortcodes()
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc     string
+		markdown string
+		expected string
+		err      string
+	}{
+		{
+			desc:     "Named arguments",
+			markdown: `{{< figure src="cat.jpg" alt=cat caption="A \"cat\"" >}}`,
+			expected: `<figure><img src="cat.jpg" alt="cat"><figcaption>A "cat"</figcaption></figure>`,
+		},
+		{
+			desc:     "Positional argument and self-closing tag",
+			markdown: "Video:\n\n{{<youtube abc123/>}}\n",
+			expected: "Video:\n\n<iframe src=\"https://www.youtube.com/embed/abc123\"></iframe>\n",
+		},
+		{
+			desc:     "Paired shortcode with markdown and nested shortcodes",
+			markdown: "{{< note type=\"warning\" >}}\n**Careful**, {{< lang >}}\n\n{{< note >}}inner{{< /note >}}\n{{< /note >}}",
+			expected: "<aside class=\"note warning\"><p><strong>Careful</strong>, en</p>\n\n<aside class=\"note \"><p>inner</p>\n</aside>\n</aside>",
+		},
+		{
+			desc:     "Escaped shortcode",
+			markdown: "Write `{{</* youtube id */>}}` to embed a video",
+			expected: "Write `{{< youtube id >}}` to embed a video",
+		},
+		{
+			desc:     "Unknown shortcode",
+			markdown: "{{< tweet 123 >}}",
+			err:      `unknown shortcode "tweet"`,
+		},
+		{
+			desc:     "Closing tag without opening one",
+			markdown: "text {{< /note >}}",
+			err:      `unexpected closing shortcode "note"`,
+		},
+		{
+			desc:     "Error in inner content",
+			markdown: "{{< note >}}{{< tweet >}}{{< /note >}}",
+			err:      `shortcode "note": unknown shortcode "tweet"`,
+		},
+		{
+			desc:     "Heading in inner content",
+			markdown: "{{< note >}}\n## Warning\n{{< /note >}}",
+			err:      `shortcode "note": headings are not supported in the content between tags`,
+		},
+		{
+			desc:     "Footnote in inner content",
+			markdown: "{{< note >}}\nSee[^1].\n\n[^1]: Note.\n{{< /note >}}",
+			err:      `shortcode "note": footnotes are not supported in the content between tags`,
+		},
+	}
+
+	for _, test := range tests {
+		md := &MarkdownFile{Language: "en"}
+		b, err := md.expandShortcodes([]byte(test.markdown))
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.desc)
+			continue
+		}
+
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.expected, string(b), test.desc)
+	}
+}
+
+func TestProcessShortcodeImages(t *testing.T) {
+	defer func() { cfg, shortcodes = config{}, nil }()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "shortcodes"), permDir))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "shortcodes", "figure.html"),
+		[]byte(`<figure><img src="{{ .Get "src" }}" alt="{{ .Get "alt" }}"></figure>`),
+		permFile,
+	))
+
+	cfg = config{TemplatesDirectory: dir, ThumbPath: "thumb"}
+
+	var err error
+	shortcodes, err = loadShortcodes()
+	require.NoError(t, err)
+
+	md, err := processMarkdownFileContent("2022/post.md", []byte("# Title\n\n{{< figure src=\"cat.jpg\" alt=\"Cat\" >}}\n"))
+	require.NoError(t, err)
+	require.Equal(t, "<figure><img src=\"cat.jpg\" alt=\"Cat\"></figure>\n", md.Body)
+	require.Equal(t, []image{{Path: "2022/cat.jpg", Alt: "Cat", ThumbPath: "thumb/2022/cat.jpg"}}, md.Images)
+}
+
+func TestLoadShortcodesWithoutDirectory(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	cfg = config{TemplatesDirectory: t.TempDir()}
+
+	templates, err := loadShortcodes()
+	require.NoError(t, err)
+	require.Nil(t, templates)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+)
+
+// footnotes renders footnote references of a post.
+// Repeated references to a note link to it without id attribute, so ids stay unique.
+// With sidenotes the note is rendered next to its first reference instead of the list
+// at the end of the post, with the same fn: and fnref: anchors.
+// Notes with lists, code and other blocks can't be inside a paragraph,
+// they are rendered right after the block with the reference.
+type footnotes struct {
+	sidenotes bool
+	seen      map[int]bool             // NoteID of rendered references
+	blocks    map[ast.Node][]*ast.Link // references to block notes by the block they follow
+}
+
+// render is a renderer hook for footnote references and the list of footnotes
+func (f *footnotes) render(renderer *mdhtml.Renderer, w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	switch node := node.(type) {
+	case *ast.Link:
+		if node.NoteID == 0 {
+			return ast.GoToNext, false
+		}
+
+		if !entering {
+			return ast.GoToNext, true
+		}
+
+		slug := footnoteSlug(node.Destination)
+		if f.seen[node.NoteID] {
+			fmt.Fprintf(w, `<sup class="footnote-ref"><a href="#fn:%s">%d</a></sup>`, slug, node.NoteID)
+			return ast.SkipChildren, true
+		}
+		f.seen[node.NoteID] = true
+
+		if !f.sidenotes {
+			return ast.GoToNext, false
+		}
+
+		fmt.Fprintf(w, `<label for="sn:%s" class="margin-toggle sidenote-number" id="fnref:%s">%d</label>`, slug, slug, node.NoteID)
+
+		if block := enclosingBlock(node); block != nil && hasBlocks(node.Footnote) {
+			f.blocks[block] = append(f.blocks[block], node)
+			return ast.SkipChildren, true
+		}
+
+		fmt.Fprintf(w, `<input type="checkbox" id="sn:%s" class="margin-toggle">`, slug)
+		fmt.Fprintf(w, `<span class="sidenote" id="fn:%s"><sup>%d</sup> `, slug, node.NoteID)
+		renderSidenote(renderer, w, node.Footnote)
+		io.WriteString(w, "</span>")
+		return ast.SkipChildren, true
+
+	case *ast.Paragraph, *ast.Heading:
+		links := f.blocks[node]
+		if entering || len(links) == 0 {
+			return ast.GoToNext, false
+		}
+
+		delete(f.blocks, node)
+		renderer.RenderNode(w, node, entering) // closing tag
+		f.renderBlocks(renderer, w, links)
+		return ast.GoToNext, true
+
+	case *ast.TableCell:
+		if links := f.blocks[node]; !entering && len(links) > 0 {
+			delete(f.blocks, node)
+			f.renderBlocks(renderer, w, links)
+		}
+
+	case *ast.List:
+		if f.sidenotes && node.IsFootnotesList {
+			return ast.SkipChildren, true
+		}
+	}
+
+	return ast.GoToNext, false
+}
+
+// renderBlocks renders sidenotes with block content after the block with references to them
+func (f *footnotes) renderBlocks(renderer *mdhtml.Renderer, w io.Writer, links []*ast.Link) {
+	render := func(node ast.Node, entering bool) ast.WalkStatus {
+		return renderer.RenderNode(w, node, entering)
+	}
+
+	for _, link := range links {
+		slug := footnoteSlug(link.Destination)
+		fmt.Fprintf(w, `<input type="checkbox" id="sn:%s" class="margin-toggle">`, slug)
+		fmt.Fprintf(w, `<div class="sidenote" id="fn:%s">`, slug)
+
+		children := link.Footnote.GetChildren()
+		if paragraph, ok := children[0].(*ast.Paragraph); ok {
+			// the number starts the first paragraph
+			fmt.Fprintf(w, "<p><sup>%d</sup> ", link.NoteID)
+			for _, node := range paragraph.Children {
+				ast.WalkFunc(node, render)
+			}
+			io.WriteString(w, "</p>\n")
+			children = children[1:]
+		} else {
+			fmt.Fprintf(w, "<sup>%d</sup>", link.NoteID)
+		}
+
+		for _, child := range children {
+			ast.WalkFunc(child, render)
+		}
+		io.WriteString(w, "</div>\n")
+	}
+}
+
+// enclosingBlock returns the paragraph, heading or table cell with the node,
+// nil if there is none
+func enclosingBlock(node ast.Node) ast.Node {
+	for parent := node.GetParent(); parent != nil; parent = parent.GetParent() {
+		switch parent.(type) {
+		case *ast.Paragraph, *ast.Heading, *ast.TableCell:
+			return parent
+		}
+	}
+	return nil
+}
+
+// hasBlocks returns true if the note has block content other than paragraphs
+func hasBlocks(note ast.Node) bool {
+	if note == nil {
+		return false
+	}
+
+	for _, child := range note.GetChildren() {
+		switch child.(type) {
+		case *ast.List, *ast.CodeBlock, *ast.BlockQuote, *ast.Aside, *ast.Table, *ast.HTMLBlock,
+			*ast.Heading, *ast.HorizontalRule, *ast.MathBlock, *ast.CaptionFigure:
+			return true
+		}
+	}
+	return false
+}
+
+// renderSidenote renders content of the footnote with paragraphs only inline,
+// paragraphs are separated with line breaks
+func renderSidenote(renderer *mdhtml.Renderer, w io.Writer, note ast.Node) {
+	if note == nil {
+		return
+	}
+
+	render := func(node ast.Node, entering bool) ast.WalkStatus {
+		return renderer.RenderNode(w, node, entering)
+	}
+
+	for i, child := range note.GetChildren() {
+		paragraph, ok := child.(*ast.Paragraph)
+		if !ok {
+			ast.WalkFunc(child, render)
+			continue
+		}
+
+		if i > 0 {
+			io.WriteString(w, "<br>")
+		}
+		for _, node := range paragraph.Children {
+			ast.WalkFunc(node, render)
+		}
+	}
+}
+
+// footnoteSlug returns the name of the footnote as it is in fn: and fnref: anchors:
+// runs of characters other than ASCII letters and digits are replaced with "-"
+func footnoteSlug(name []byte) string {
+	var (
+		buf strings.Builder
+		sym bool
+	)
+
+	for _, c := range name {
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			buf.WriteByte(c)
+			sym = false
+		} else if !sym {
+			buf.WriteByte('-')
+			sym = true
+		}
+	}
+
+	return strings.Trim(buf.String(), "-")
+}
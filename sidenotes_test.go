@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderFootnotes(t *testing.T) {
+	const markdown = "Text[^a] and again[^a], more^[Inline *note*].\n\n[^a]: First.\n\n    Second paragraph.\n"
+
+	opts := defaultMarkdownOptions
+	opts.extensions |= markdownExtensions["footnotes"]
+
+	tests := []struct {
+		desc      string
+		sidenotes bool
+		expected  string
+	}{
+		{
+			desc: "Footnotes",
+			expected: "<p>Text<sup class=\"footnote-ref\" id=\"fnref:a\"><a href=\"#fn:a\">1</a></sup> and again" +
+				"<sup class=\"footnote-ref\"><a href=\"#fn:a\">1</a></sup>, more" +
+				"<sup class=\"footnote-ref\" id=\"fnref:Inline-note\"><a href=\"#fn:Inline-note\">2</a></sup>.</p>\n\n" +
+				"<div class=\"footnotes\">\n\n<hr>\n\n<ol>\n" +
+				"<li id=\"fn:a\"><p>First.</p>\n\n<p>Second paragraph.</p></li>\n\n" +
+				"<li id=\"fn:Inline-note\">Inline <em>note</em></li>\n</ol>\n\n</div>\n",
+		},
+		{
+			desc:      "Sidenotes",
+			sidenotes: true,
+			expected: "<p>Text" +
+				"<label for=\"sn:a\" class=\"margin-toggle sidenote-number\" id=\"fnref:a\">1</label>" +
+				"<input type=\"checkbox\" id=\"sn:a\" class=\"margin-toggle\">" +
+				"<span class=\"sidenote\" id=\"fn:a\"><sup>1</sup> First.<br>Second paragraph.</span> and again" +
+				"<sup class=\"footnote-ref\"><a href=\"#fn:a\">1</a></sup>, more" +
+				"<label for=\"sn:Inline-note\" class=\"margin-toggle sidenote-number\" id=\"fnref:Inline-note\">2</label>" +
+				"<input type=\"checkbox\" id=\"sn:Inline-note\" class=\"margin-toggle\">" +
+				"<span class=\"sidenote\" id=\"fn:Inline-note\"><sup>2</sup> Inline <em>note</em></span>.</p>\n",
+		},
+	}
+
+	for _, test := range tests {
+		opts.sidenotes = test.sidenotes
+		body, _ := renderMarkdown([]byte(markdown), opts)
+		require.Equal(t, test.expected, string(body), test.desc)
+	}
+}
+
+func TestRenderBlockSidenotes(t *testing.T) {
+	opts := defaultMarkdownOptions
+	opts.extensions |= markdownExtensions["footnotes"]
+	opts.sidenotes = true
+
+	tests := []struct {
+		desc     string
+		markdown string
+		expected string
+	}{
+		{
+			desc:     "After paragraph",
+			markdown: "Text[^a] more.\n\nNext.\n\n[^a]: First.\n\n    - one\n    - two\n",
+			expected: "<p>Text<label for=\"sn:a\" class=\"margin-toggle sidenote-number\" id=\"fnref:a\">1</label> more.</p>\n" +
+				"<input type=\"checkbox\" id=\"sn:a\" class=\"margin-toggle\">" +
+				"<div class=\"sidenote\" id=\"fn:a\"><p><sup>1</sup> First.</p>\n\n<ul>\n<li>one</li>\n<li>two</li>\n</ul></div>\n\n" +
+				"<p>Next.</p>\n",
+		},
+		{
+			desc:     "In list item",
+			markdown: "- item[^a]\n- other\n\n[^a]: Note.\n\n        code\n",
+			expected: "<ul>\n<li>item<label for=\"sn:a\" class=\"margin-toggle sidenote-number\" id=\"fnref:a\">1</label>" +
+				"<input type=\"checkbox\" id=\"sn:a\" class=\"margin-toggle\">" +
+				"<div class=\"sidenote\" id=\"fn:a\"><p><sup>1</sup> Note.</p>\n\n<pre><code>code\n</code></pre></div>\n</li>\n" +
+				"<li>other</li>\n</ul>\n",
+		},
+	}
+
+	for _, test := range tests {
+		body, _ := renderMarkdown([]byte(test.markdown), opts)
+		require.Equal(t, test.expected, string(body), test.desc)
+	}
+}
+
+func TestProcessSidenotes(t *testing.T) {
+	md, err := processMarkdownFileContent("post.md", []byte("---\nsidenotes: true\n---\n\nText[^1]\n\n[^1]: Note\n"))
+	require.NoError(t, err)
+	require.True(t, md.Sidenotes)
+	require.Contains(t, md.Body, `<span class="sidenote" id="fn:1"><sup>1</sup> Note</span>`)
+	require.NotContains(t, md.Body, `class="footnotes"`)
+	require.Nil(t, md.Params)
+}
+
+func TestFootnoteSlug(t *testing.T) {
+	require.Equal(t, "my-note-2", footnoteSlug([]byte("  my note (2)!")))
+	require.Equal(t, "1", footnoteSlug([]byte("1")))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	moreSeparator = regexp.MustCompile(`<!--\s*more\s*-->`)
+	htmlTag       = regexp.MustCompile(`<[^>]*>`)
+	htmlTagName   = regexp.MustCompile(`^</?([a-zA-Z][a-zA-Z0-9]*)`)
+	word          = regexp.MustCompile(`\S+`)
+)
+
+// voidElements are HTML elements without closing tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// summary returns HTML summary of the post and true if it's shorter than the post.
+// The summary is the part of markdown body before `<!--more-->` separator,
+// or the first words of the HTML body, see truncateHTML.
+func summary(markdownBody []byte, body string, words int, opts markdownOptions) (string, bool) {
+	if loc := findMoreSeparator(markdownBody); loc != nil {
+		truncated := len(bytes.TrimSpace(markdownBody[loc[1]:])) > 0
+		html, _ := renderMarkdown(markdownBody[:loc[0]], opts)
+		return string(html), truncated
+	}
+
+	return truncateHTML(body, words)
+}
+
+// findMoreSeparator returns location of the first `<!--more-->` separator
+// outside of fenced code blocks, nil if there is none
+func findMoreSeparator(b []byte) []int {
+	closing := "" // marker of the open code block
+	offset := 0
+
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		text := strings.TrimRight(string(line), "\r\n")
+		m := fenceMarker.FindStringSubmatch(text)
+
+		switch {
+		case closing != "":
+			if m != nil && m[1][0] == closing[0] && len(m[1]) >= len(closing) &&
+				strings.TrimSpace(text[len(m[0]):]) == "" {
+				closing = ""
+			}
+		case m != nil:
+			closing = m[1]
+		default:
+			if loc := moreSeparator.FindIndex(line); loc != nil {
+				return []int{offset + loc[0], offset + loc[1]}
+			}
+		}
+
+		offset += len(line)
+	}
+
+	return nil
+}
+
+// truncateHTML returns the first words of HTML, closing all tags that are left open,
+// and true if some words were cut off. If limit is 0, HTML is returned as is.
+func truncateHTML(s string, limit int) (string, bool) {
+	if limit <= 0 {
+		return s, false
+	}
+
+	var (
+		buf   strings.Builder
+		open  []string // names of open tags
+		count int
+		rest  = s
+	)
+
+	for rest != "" {
+		text, tag := rest, ""
+		if loc := htmlTag.FindStringIndex(rest); loc != nil {
+			text, tag = rest[:loc[0]], rest[loc[0]:loc[1]]
+		}
+
+		words := word.FindAllStringIndex(text, -1)
+		if count+len(words) >= limit {
+			end := words[limit-count-1][1]
+			if !hasWords(text[end:] + rest[len(text):]) {
+				return s, false
+			}
+
+			buf.WriteString(text[:end])
+			for i := len(open) - 1; i >= 0; i-- {
+				buf.WriteString("</" + open[i] + ">")
+			}
+			return buf.String(), true
+		}
+		count += len(words)
+
+		buf.WriteString(text)
+		buf.WriteString(tag)
+		rest = rest[len(text)+len(tag):]
+
+		open = updateOpenTags(open, tag)
+	}
+
+	return s, false
+}
+
+// updateOpenTags adds opening tag to the list of open tags
+// and removes the matching tag for closing tag
+func updateOpenTags(open []string, tag string) []string {
+	match := htmlTagName.FindStringSubmatch(tag)
+	if match == nil || strings.HasSuffix(tag, "/>") {
+		return open // comment, doctype or self-closing tag
+	}
+
+	name := strings.ToLower(match[1])
+	if voidElements[name] {
+		return open
+	}
+
+	if !strings.HasPrefix(tag, "</") {
+		return append(open, name)
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == name {
+			return open[:i]
+		}
+	}
+	return open
+}
+
+// hasWords reports whether HTML has any text
+func hasWords(s string) bool {
+	return strings.TrimSpace(htmlTag.ReplaceAllString(s, "")) != ""
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		desc      string
+		content   string
+		length    int
+		summary   string
+		truncated bool
+	}{
+		{
+			desc:      "More separator",
+			content:   "---\ndate: 2006-01-02\n---\nFirst paragraph.\n\n<!--more-->\n\nSecond paragraph.\n",
+			length:    1,
+			summary:   "<p>First paragraph.</p>\n",
+			truncated: true,
+		},
+		{
+			desc:      "More separator at the end",
+			content:   "First paragraph.\n\n<!--more-->\n",
+			length:    1,
+			summary:   "<p>First paragraph.</p>\n",
+			truncated: false,
+		},
+		{
+			desc:      "More separator in code block",
+			content:   "Code:\n\n```html\n<!--more-->\n```\n\nEnd.\n",
+			length:    0,
+			summary:   "<p>Code:</p>\n\n<pre><code class=\"language-html\">&lt;!--more--&gt;\n</code></pre>\n\n<p>End.</p>\n",
+			truncated: false,
+		},
+		{
+			desc:      "More separator after code block",
+			content:   "~~~~\n```\n<!--more-->\n~~~~\n\nFirst.\n\n<!--more-->\n\nSecond.\n",
+			length:    0,
+			summary:   "<pre><code>```\n&lt;!--more--&gt;\n</code></pre>\n\n<p>First.</p>\n",
+			truncated: true,
+		},
+		{
+			desc:      "First words",
+			content:   "First *paragraph* with **some words**.\n\nSecond paragraph.\n",
+			length:    5,
+			summary:   "<p>First <em>paragraph</em> with <strong>some words</strong></p>",
+			truncated: true,
+		},
+		{
+			desc:      "First words inside nested tags",
+			content:   "- item one\n- item **two three** four\n",
+			length:    4,
+			summary:   "<ul>\n<li>item one</li>\n<li>item <strong>two</strong></li></ul>",
+			truncated: true,
+		},
+		{
+			desc:      "Short post",
+			content:   "One two three.\n",
+			length:    3,
+			summary:   "<p>One two three.</p>\n",
+			truncated: false,
+		},
+		{
+			desc:      "Summary length 0",
+			content:   "One two three.\n",
+			length:    0,
+			summary:   "<p>One two three.</p>\n",
+			truncated: false,
+		},
+	}
+
+	for _, test := range tests {
+		cfg = config{SummaryLength: test.length}
+		md, err := processMarkdownFileContent("post.md", []byte(test.content))
+		require.NoError(t, err, test.desc)
+		require.Equal(t, test.summary, md.Summary, test.desc)
+		require.Equal(t, test.truncated, md.Truncated, test.desc)
+	}
+}
+
+func TestTruncateHTML(t *testing.T) {
+	tests := []struct {
+		in        string
+		limit     int
+		out       string
+		truncated bool
+	}{
+		{
+			in:        `<p>One<br>two <img src="x.png" alt="x"/> three <!-- comment --> four</p>`,
+			limit:     3,
+			out:       `<p>One<br>two <img src="x.png" alt="x"/> three</p>`,
+			truncated: true,
+		},
+		{
+			in:        `<div><p>One two</p><p>three</p></div>`,
+			limit:     2,
+			out:       `<div><p>One two</p></div>`,
+			truncated: true,
+		},
+		{
+			in:        `<div><p>One two</p><p></p></div>`,
+			limit:     2,
+			out:       `<div><p>One two</p><p></p></div>`,
+			truncated: false,
+		},
+	}
+
+	for _, test := range tests {
+		out, truncated := truncateHTML(test.in, test.limit)
+		require.Equal(t, test.out, out, test.in)
+		require.Equal(t, test.truncated, truncated, test.in)
+	}
+}
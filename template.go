@@ -2,11 +2,11 @@ package main
 
 import (
 	"encoding/json"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -23,10 +23,16 @@ var fm = template.FuncMap{
 	"allLanguageVariations": allLanguageVariations, // all pages that has the same ID as current page
 	"langGetParameter":      langGetParameter,      // get lang parameter value for page
 	"langToGetParameter":    langToGetParameter,    // replace lang suffix with .html and append ?lang=ru, e.g. index_ru.html -> index.html?lang=ru
-	"year":                  year,                  // gets the year from date of format "2006-01-02"
+	"year":                  year,                  // gets the year from post date
 	"i18n":                  i18n,                  // translate string
 	"stripTags":             stripTags,             // remove html tags
 	"config":                getConfigValue,        // get config value
+	"language":              getLanguageConfig,     // get settings of the language from config file
+	"param":                 param,                 // get custom metadata value by dot-separated key
+	"paramString":           paramString,           // get custom metadata value as a string
+	"paramBool":             paramBool,             // get custom metadata value as a bool
+	"paramInt":              paramInt,              // get custom metadata value as an int
+	"paramStrings":          paramStrings,          // get custom metadata value as a list of strings
 	"sort":                  sortFiles,
 }
 
@@ -188,20 +194,47 @@ func langToGetParameter(url string) string {
 	//       rewrite ^(.*)\.html$ /$1_$lang_code.html last;
 	//     }
 	//   }
+	//
+	// With config.PrettyURLs "index.html" is removed: post/index_ru.html -> post/?lang=ru,
+	// the nginx `index` directive keeps the "lang" parameter.
 	if langSuffix.MatchString(url) {
 		match := langSuffix.FindStringSubmatch(url)
-		return url[:len(url)-len(match[0])] + ".html?lang=" + match[1]
+		return prettyURL(url[:len(url)-len(match[0])] + ".html?lang=" + match[1])
+	}
+
+	return prettyURL(url)
+}
+
+// prettyURL removes "index.html" from the URL path if config.PrettyURLs is enabled,
+// e.g. 2022/post/index.html -> 2022/post/, index.html -> ./
+func prettyURL(url string) string {
+	if !cfg.PrettyURLs {
+		return url
+	}
+
+	p, rest := url, ""
+	if i := strings.IndexAny(url, "?#"); i != -1 {
+		p, rest = url[:i], url[i:]
 	}
 
-	return url
+	if p != "index.html" && !strings.HasSuffix(p, "/index.html") {
+		return url
+	}
+
+	p = strings.TrimSuffix(p, "index.html")
+	if p == "" {
+		p = "./"
+	}
+
+	return p + rest
 }
 
-func year(date string) string {
-	if len(date) < 4 {
+func year(d date) string {
+	if d.IsZero() {
 		return ""
 	}
 
-	return date[:4]
+	return strconv.Itoa(d.Year())
 }
 
 func i18n(id string, lang string) string {
@@ -226,14 +259,22 @@ func getConfigValue(key string) string {
 	return cfg.GetString(key)
 }
 
+func getLanguageConfig(lang string) languageConfig {
+	return cfg.language(lang)
+}
+
+// sortFiles returns sorted copy of files,
+// the original slice is shared between pages rendered in parallel
 func sortFiles(files []*MarkdownFile, field string) []*MarkdownFile {
+	files = append([]*MarkdownFile(nil), files...)
+
 	switch field {
 	case "created":
 		sort.Sort(ByCreated(files))
 	case "order":
 		sort.Sort(ByOrder(files))
 	default:
-		log.Printf("unknown sort field: %s", field)
+		problems.add(problemTemplate, "", errors.Errorf("unknown sort field %q", field))
 	}
 	return files
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -279,12 +280,52 @@ func TestLangToGetParameter(t *testing.T) {
 		},
 	}
 
+	cfg = config{}
 	for _, test := range tests {
 		result := langToGetParameter(test.url)
 		require.Equal(t, test.expectedResult, result)
 	}
 }
 
+func TestLangToGetParameterPrettyURLs(t *testing.T) {
+	tests := []struct {
+		url            string
+		expectedResult string
+	}{
+		{
+			url:            "2021/post/index.html",
+			expectedResult: "2021/post/",
+		},
+		{
+			url:            "2021/post/index_ru.html",
+			expectedResult: "2021/post/?lang=ru",
+		},
+		{
+			url:            "index.html",
+			expectedResult: "./",
+		},
+		{
+			url:            "index_ru.html",
+			expectedResult: "./?lang=ru",
+		},
+		{
+			url:            "2021/post.html",
+			expectedResult: "2021/post.html",
+		},
+		{
+			url:            "2021/myindex.html",
+			expectedResult: "2021/myindex.html",
+		},
+	}
+
+	cfg = config{PrettyURLs: true}
+	defer func() { cfg = config{} }()
+
+	for _, test := range tests {
+		require.Equal(t, test.expectedResult, langToGetParameter(test.url), test.url)
+	}
+}
+
 func TestLangGetParameter(t *testing.T) {
 	tests := []struct {
 		url             string
@@ -333,15 +374,15 @@ func TestLangGetParameter(t *testing.T) {
 
 func TestYear(t *testing.T) {
 	tests := []struct {
-		date string
+		date date
 		year string
 	}{
 		{
-			date: "2006-01-02",
+			date: date{Time: time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)},
 			year: "2006",
 		},
 		{
-			date: "",
+			date: date{},
 			year: "",
 		},
 	}
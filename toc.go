@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// Default levels of headings in the table of contents,
+// overridden by `toc_min_level` and `toc_max_level` metadata
+const (
+	defaultTOCMinLevel = 2
+	defaultTOCMaxLevel = 3
+)
+
+// tocEntry is a heading in the table of contents
+type tocEntry struct {
+	Level    int
+	ID       string // id attribute of the heading
+	Text     string // heading text without markup
+	Children []*tocEntry
+}
+
+// transliteration of Cyrillic letters in heading IDs
+var transliteration = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// renderMarkdown renders markdown to HTML, headings get IDs from their text (see slugify).
+// It returns headings of all levels in the order they appear.
+func renderMarkdown(b []byte, opts markdownOptions) ([]byte, []*tocEntry) {
+	doc := markdown.Parse(normalizeFences(b), parser.NewWithExtensions(opts.extensions))
+
+	var (
+		headings []*tocEntry
+		used     = map[string]bool{}
+	)
+
+	// IDs set in markdown with {#id} are kept
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, ok := node.(*ast.Heading); ok && entering && heading.HeadingID != "" {
+			used[heading.HeadingID] = true
+		}
+		return ast.GoToNext
+	})
+
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering || heading.IsTitleblock {
+			return ast.GoToNext
+		}
+
+		if heading.HeadingID == "" {
+			heading.HeadingID = uniqueID(slugify(headingText(heading, 0)), used)
+		}
+
+		headings = append(headings, &tocEntry{
+			Level: heading.Level,
+			ID:    heading.HeadingID,
+			Text:  headingText(heading, opts.flags),
+		})
+		return ast.SkipChildren
+	})
+
+	var renderer *mdhtml.Renderer
+	notes := &footnotes{sidenotes: opts.sidenotes, seen: map[int]bool{}, blocks: map[ast.Node][]*ast.Link{}}
+	renderer = mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags: opts.flags,
+		RenderNodeHook: func(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+			if opts.highlight {
+				if status, ok := highlightCode(renderer, w, node); ok {
+					return status, true
+				}
+			}
+			if opts.math {
+				if status, ok := renderMath(renderer, w, node, entering, opts.source); ok {
+					return status, true
+				}
+			}
+			return notes.render(renderer, w, node, entering)
+		},
+	})
+
+	return markdown.Render(doc, renderer), headings
+}
+
+// headingText returns text of the heading without markup.
+// With smartypants in flags the text is changed the same way as in the rendered heading.
+func headingText(heading *ast.Heading, flags mdhtml.Flags) string {
+	var (
+		buf bytes.Buffer
+		sr  *mdhtml.SPRenderer
+	)
+	if flags&mdhtml.Smartypants != 0 {
+		sr = mdhtml.NewSmartypantsRenderer(flags)
+	}
+
+	ast.WalkFunc(heading, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch n := node.(type) {
+		case *ast.Text:
+			if sr == nil {
+				buf.Write(n.Literal)
+				break
+			}
+			var escaped, smart bytes.Buffer
+			mdhtml.EscapeHTML(&escaped, n.Literal)
+			sr.Process(&smart, escaped.Bytes())
+			buf.WriteString(html.UnescapeString(smart.String()))
+		case *ast.Code:
+			buf.Write(n.Literal)
+		}
+		return ast.GoToNext
+	})
+
+	return strings.TrimSpace(buf.String())
+}
+
+// slugify returns lowercase text with words separated by dashes,
+// Cyrillic letters are transliterated, e.g. "Привет, мир!" -> "privet-mir"
+func slugify(text string) string {
+	var buf strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(text) {
+		s, ok := transliteration[r]
+		switch {
+		case ok:
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			s = string(r)
+		default:
+			dash = buf.Len() > 0
+			continue
+		}
+
+		if s == "" {
+			continue
+		}
+
+		if dash {
+			buf.WriteByte('-')
+			dash = false
+		}
+		buf.WriteString(s)
+	}
+
+	return buf.String()
+}
+
+// uniqueID returns id, or id with number suffix if it's already used
+func uniqueID(id string, used map[string]bool) string {
+	if id == "" {
+		id = "section"
+	}
+
+	unique := id
+	for i := 1; used[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", id, i)
+	}
+
+	used[unique] = true
+	return unique
+}
+
+// buildTOC returns nested table of contents of headings between minLevel and maxLevel
+func buildTOC(headings []*tocEntry, minLevel, maxLevel int) []*tocEntry {
+	var (
+		toc   []*tocEntry
+		stack []*tocEntry // path from the top level to the last added entry
+	)
+
+	for _, heading := range headings {
+		if heading.Level < minLevel {
+			stack = nil // the next headings are in another section
+			continue
+		}
+		if heading.Level > maxLevel {
+			continue
+		}
+
+		entry := &tocEntry{Level: heading.Level, ID: heading.ID, Text: heading.Text}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			toc = append(toc, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+
+		stack = append(stack, entry)
+	}
+
+	return toc
+}
+
+// typographTOC applies typograph to texts of the entries, as it's applied to the headings
+func typographTOC(entries []*tocEntry, lang string) {
+	for _, entry := range entries {
+		entry.Text = html.UnescapeString(typograph(escapeText(entry.Text), lang))
+		typographTOC(entry.Children, lang)
+	}
+}
+
+// renderTOC returns table of contents as nested HTML lists, empty string if there are no entries
+func renderTOC(toc []*tocEntry) string {
+	if len(toc) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	buf.WriteString("<nav class=\"toc\">\n")
+	writeTOCList(&buf, toc)
+	buf.WriteString("</nav>\n")
+	return buf.String()
+}
+
+func writeTOCList(buf *strings.Builder, entries []*tocEntry) {
+	buf.WriteString("<ul>\n")
+	for _, entry := range entries {
+		fmt.Fprintf(buf, "<li><a href=\"#%s\">%s</a>", html.EscapeString(entry.ID), html.EscapeString(entry.Text))
+		if len(entry.Children) > 0 {
+			buf.WriteString("\n")
+			writeTOCList(buf, entry.Children)
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		slug string
+	}{
+		{text: "Hello, World!", slug: "hello-world"},
+		{text: "  Go 1.17 release  ", slug: "go-1-17-release"},
+		{text: "Привет, мир", slug: "privet-mir"},
+		{text: "Щука и ёж", slug: "shchuka-i-yozh"},
+		{text: "Объявление", slug: "obyavlenie"},
+		{text: "日本語", slug: "日本語"},
+		{text: "?!", slug: ""},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.slug, slugify(test.text), test.text)
+	}
+}
+
+func TestRenderMarkdownHeadingIDs(t *testing.T) {
+	body, headings := renderMarkdown([]byte(
+		"## Intro\n\nText\n\n### Детали `code`\n\n## Intro\n\n## Custom {#my-id}\n\n## ?\n",
+	), defaultMarkdownOptions)
+
+	require.Equal(
+		t,
+		"<h2 id=\"intro\">Intro</h2>\n\n<p>Text</p>\n\n"+
+			"<h3 id=\"detali-code\">Детали <code>code</code></h3>\n\n"+
+			"<h2 id=\"intro-1\">Intro</h2>\n\n"+
+			"<h2 id=\"my-id\">Custom</h2>\n\n"+
+			"<h2 id=\"section\">?</h2>\n",
+		string(body),
+	)
+	require.Equal(
+		t,
+		[]*tocEntry{
+			{Level: 2, ID: "intro", Text: "Intro"},
+			{Level: 3, ID: "detali-code", Text: "Детали code"},
+			{Level: 2, ID: "intro-1", Text: "Intro"},
+			{Level: 2, ID: "my-id", Text: "Custom"},
+			{Level: 2, ID: "section", Text: "?"},
+		},
+		headings,
+	)
+}
+
+func TestBuildTOC(t *testing.T) {
+	headings := []*tocEntry{
+		{Level: 2, ID: "a", Text: "A"},
+		{Level: 3, ID: "a1", Text: "A1"},
+		{Level: 4, ID: "a1x", Text: "A1x"},
+		{Level: 3, ID: "a2", Text: "A2"},
+		{Level: 2, ID: "b", Text: "B"},
+		{Level: 4, ID: "b1", Text: "B1"},
+	}
+
+	require.Equal(
+		t,
+		[]*tocEntry{
+			{Level: 2, ID: "a", Text: "A", Children: []*tocEntry{
+				{Level: 3, ID: "a1", Text: "A1"},
+				{Level: 3, ID: "a2", Text: "A2"},
+			}},
+			{Level: 2, ID: "b", Text: "B"},
+		},
+		buildTOC(headings, 2, 3),
+	)
+
+	require.Equal(
+		t,
+		[]*tocEntry{
+			{Level: 3, ID: "a1", Text: "A1", Children: []*tocEntry{
+				{Level: 4, ID: "a1x", Text: "A1x"},
+			}},
+			{Level: 3, ID: "a2", Text: "A2"},
+			{Level: 4, ID: "b1", Text: "B1"},
+		},
+		buildTOC(headings, 3, 4),
+	)
+}
+
+func TestProcessTOC(t *testing.T) {
+	cfg = config{}
+
+	md, err := processMarkdownFileContent("post.md", []byte(
+		"---\ntoc_max_level: 4\n---\n# Title\n## One & two\n#### Deep\n## Three\n",
+	))
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"<nav class=\"toc\">\n<ul>\n"+
+			"<li><a href=\"#one-two\">One &amp; two</a>\n<ul>\n<li><a href=\"#deep\">Deep</a></li>\n</ul>\n</li>\n"+
+			"<li><a href=\"#three\">Three</a></li>\n"+
+			"</ul>\n</nav>\n",
+		md.TOCHTML,
+	)
+
+	md, err = processMarkdownFileContent("post.md", []byte("# Title\nNo headings"))
+	require.NoError(t, err)
+	require.Nil(t, md.TOC)
+	require.Equal(t, "", md.TOCHTML)
+}
+
+func TestProcessTOCText(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	tests := []struct {
+		desc    string
+		cfg     config
+		source  string
+		heading string
+		text    string
+	}{
+		{
+			desc:    "Smartypants",
+			source:  "post.md",
+			heading: "<h2 id=\"quoted-text\">&ldquo;Quoted&rdquo; - text</h2>",
+			text:    "“Quoted” - text",
+		},
+		{
+			desc:    "Typography",
+			cfg:     config{Typography: true},
+			source:  "post_ru.md",
+			heading: "<h2 id=\"quoted-text\">«Quoted»\u00a0— text</h2>",
+			text:    "«Quoted»\u00a0— text",
+		},
+	}
+
+	for _, test := range tests {
+		cfg = test.cfg
+		md, err := processMarkdownFileContent(test.source, []byte("# Title\n## \"Quoted\" - text\n"))
+		require.NoError(t, err, test.desc)
+		require.Contains(t, md.Body, test.heading, test.desc)
+		require.Equal(t, test.text, md.TOC[0].Text, test.desc)
+		require.Contains(t, md.TOCHTML, ">"+test.text+"</a>", test.desc)
+	}
+}
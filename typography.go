@@ -0,0 +1,150 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// typographyRules are typography rules of the language
+type typographyRules struct {
+	quotes     [4]string      // opening and closing quotes, then opening and closing quotes inside quotes
+	shortWords *regexp.Regexp // words followed by non-breaking space, the word is the second group
+}
+
+// typographyLanguages are rules by language code, languages without rules use "en"
+var typographyLanguages = map[string]typographyRules{
+	"en": {
+		quotes: [4]string{"“", "”", "‘", "’"},
+	},
+	"ru": {
+		quotes: [4]string{"«", "»", "„", "“"},
+		shortWords: regexp.MustCompile(
+			`(?i)(^|[\s(«„\x{00a0}])(а|без|в|во|да|для|до|за|и|из|к|ко|на|над|не|ни|но|о|об|от|по|под|при|про|с|со|у)\s+`,
+		),
+	},
+}
+
+var (
+	// inlineElements don't start a new block of text, other tags reset quotes
+	inlineElements = map[string]bool{
+		"a": true, "abbr": true, "b": true, "del": true, "em": true, "i": true, "ins": true, "mark": true,
+		"q": true, "s": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true, "u": true,
+	}
+	// typographySkip are elements which content is left as is
+	typographySkip = map[string]bool{"code": true, "pre": true, "kbd": true, "samp": true, "script": true, "style": true, "math": true}
+	// spacedDash is a hyphen or a dash between spaces, e.g. "word - word"
+	spacedDash = regexp.MustCompile(` +(-{1,3}|–|—) +`)
+)
+
+// typography reports whether typograph should process posts in the language
+func (c config) typography(lang string) bool {
+	if l := c.language(lang); l.Typography != nil {
+		return *l.Typography
+	}
+	return c.Typography
+}
+
+// typograph improves typography of the text in HTML, content of typographySkip elements is not changed:
+// quotes are replaced with the language quotes, "..." with ellipsis,
+// hyphens between spaces with em dashes, with non-breaking space before them,
+// and short words (see typographyRules) are glued to the next word with non-breaking space.
+func typograph(body, lang string) string {
+	rules, ok := typographyLanguages[lang]
+	if !ok {
+		rules = typographyLanguages["en"]
+	}
+
+	var (
+		buf   strings.Builder
+		skip  int  // depth of typographySkip elements
+		depth int  // depth of open quotes
+		prev  rune // last character of the text, to tell opening quotes from closing ones
+		rest  = body
+	)
+
+	for rest != "" {
+		text, tag := rest, ""
+		if loc := htmlTag.FindStringIndex(rest); loc != nil {
+			text, tag = rest[:loc[0]], rest[loc[0]:loc[1]]
+		}
+		rest = rest[len(text)+len(tag):]
+
+		if skip > 0 || text == "" {
+			buf.WriteString(text)
+		} else {
+			text = rules.process(html.UnescapeString(text), &prev, &depth)
+			buf.WriteString(escapeText(text))
+		}
+
+		buf.WriteString(tag)
+
+		match := htmlTagName.FindStringSubmatch(tag)
+		if match == nil {
+			continue
+		}
+
+		name := strings.ToLower(match[1])
+		if !inlineElements[name] {
+			prev, depth = 0, 0
+		}
+
+		if typographySkip[name] {
+			if strings.HasPrefix(tag, "</") {
+				skip--
+			} else if !strings.HasSuffix(tag, "/>") {
+				skip++
+			}
+		}
+	}
+
+	return buf.String()
+}
+
+// process applies rules to the unescaped text.
+// prev and depth keep the state between texts of different elements.
+func (r typographyRules) process(text string, prev *rune, depth *int) string {
+	text = strings.ReplaceAll(text, "...", "…")
+	text = spacedDash.ReplaceAllString(text, "\u00a0— ")
+
+	if r.shortWords != nil {
+		// applied twice for short words in a row, e.g. "и в доме"
+		text = r.shortWords.ReplaceAllString(text, "$1$2\u00a0")
+		text = r.shortWords.ReplaceAllString(text, "$1$2\u00a0")
+	}
+
+	var buf strings.Builder
+	for _, c := range text {
+		if c == '"' || c == '“' || c == '”' {
+			if isQuoteOpening(*prev) {
+				buf.WriteString(r.quotes[2*(*depth%2)])
+				*depth++
+				*prev = 0 // quotes right after the opening one are opening too
+			} else {
+				if *depth > 0 {
+					*depth--
+				}
+				buf.WriteString(r.quotes[2*(*depth%2)+1])
+				*prev = c
+			}
+
+			continue
+		}
+
+		buf.WriteRune(c)
+		*prev = c
+	}
+
+	return buf.String()
+}
+
+// isQuoteOpening reports whether a quote after the character is an opening one
+func isQuoteOpening(prev rune) bool {
+	return prev == 0 || unicode.IsSpace(prev) || strings.ContainsRune("([{—–-/«„", prev)
+}
+
+// escapeText escapes characters that can't be in HTML text
+func escapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
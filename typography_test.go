@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypograph(t *testing.T) {
+	tests := []struct {
+		desc     string
+		lang     string
+		body     string
+		expected string
+	}{
+		{
+			desc:     "English quotes",
+			lang:     "en",
+			body:     `<p>He said &quot;it's <em>&quot;fine&quot;</em>&quot; and left...</p>`,
+			expected: "<p>He said “it's <em>‘fine’</em>” and left…</p>",
+		},
+		{
+			desc:     "Russian quotes after smartypants",
+			lang:     "ru",
+			body:     "<p>&ldquo;Слово &ldquo;в&rdquo; кавычках&rdquo;</p>\n\n<p>&quot;Новый абзац&quot;</p>",
+			expected: "<p>«Слово „в“ кавычках»</p>\n\n<p>«Новый абзац»</p>",
+		},
+		{
+			desc:     "Russian short words and dashes",
+			lang:     "ru",
+			body:     "<p>Жизнь - это путь и в горы, и к морю -- без конца. <a href=\"/\">Ссылка</a> &amp; код</p>",
+			expected: "<p>Жизнь\u00a0— это путь и\u00a0в\u00a0горы, и\u00a0к\u00a0морю\u00a0— без\u00a0конца. <a href=\"/\">Ссылка</a> &amp; код</p>",
+		},
+		{
+			desc:     "Code is skipped",
+			lang:     "en",
+			body:     "<p>Run <code>echo \"a - b\"...</code> - \"done\"</p>\n<pre><code>x := \"y\"\n</code></pre>",
+			expected: "<p>Run <code>echo \"a - b\"...</code>\u00a0— “done”</p>\n<pre><code>x := \"y\"\n</code></pre>",
+		},
+		{
+			desc:     "Unknown language uses English rules",
+			lang:     "de",
+			body:     "<p>&quot;Hallo&quot; &lt;Welt&gt;</p>",
+			expected: "<p>“Hallo” &lt;Welt&gt;</p>",
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, typograph(test.body, test.lang), test.desc)
+	}
+}
+
+func TestProcessTypography(t *testing.T) {
+	defer func() { cfg = config{} }()
+
+	disabled := false
+	cfg = config{
+		Typography: true,
+		Languages:  map[string]languageConfig{"de": {Typography: &disabled}},
+	}
+
+	md, err := processMarkdownFileContent("post_ru.md", []byte("\"Привет\" - сказал он\n\n<!--more-->\n\nИ ушёл"))
+	require.NoError(t, err)
+	require.Equal(t, "<p>«Привет»\u00a0— сказал он</p>\n\n<!--more-->\n\n<p>И\u00a0ушёл</p>\n", md.Body)
+	require.Equal(t, "<p>«Привет»\u00a0— сказал он</p>\n", md.Summary)
+
+	md, err = processMarkdownFileContent("post_de.md", []byte("\"Hallo\" - sagte er"))
+	require.NoError(t, err)
+	require.Equal(t, "<p>&ldquo;Hallo&rdquo; - sagte er</p>\n", md.Body)
+}
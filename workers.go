@@ -0,0 +1,50 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+// workers returns number of goroutines used to process files
+func (c config) workers() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// forEach calls fn for every index from 0 to count-1 using up to `workers` goroutines.
+// It waits for all calls to finish and returns the error with the lowest index,
+// so that the result doesn't depend on scheduling.
+func forEach(workers, count int, fn func(i int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	errs := make([]error, count)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
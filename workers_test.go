@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEach(t *testing.T) {
+	var calls int32
+	results := make([]int, 100)
+
+	err := forEach(4, len(results), func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		results[i] = i * 2
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, int32(100), calls)
+	for i, r := range results {
+		require.Equal(t, i*2, r)
+	}
+}
+
+func TestForEachReturnsErrorWithLowestIndex(t *testing.T) {
+	err := forEach(8, 10, func(i int) error {
+		if i == 3 || i == 7 {
+			return errors.New("failed " + string(rune('0'+i)))
+		}
+		return nil
+	})
+
+	require.EqualError(t, err, "failed 3")
+}